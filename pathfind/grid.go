@@ -0,0 +1,187 @@
+package pathfind
+
+// Point is a cell of a grid.
+type Point struct {
+	X, Y int
+}
+
+// Grid is a map of cells, some of which can be walked through. Cells outside
+// the grid must not be walkable.
+type Grid interface {
+	Walkable(p Point) bool
+}
+
+// GridGraph is the Graph of a Grid where each step goes up, down, left or
+// right and costs 1.
+type GridGraph struct {
+	Grid
+}
+
+var directions = []Point{{1, 0}, {-1, 0}, {0, -1}, {0, 1}}
+
+func (g GridGraph) Neighbors(p Point) []Point {
+	neighbors := make([]Point, 0, 4)
+	for _, d := range directions {
+		next := Point{p.X + d.X, p.Y + d.Y}
+		if g.Walkable(next) {
+			neighbors = append(neighbors, next)
+		}
+	}
+	return neighbors
+}
+
+func (g GridGraph) Cost(from, to Point) int {
+	return 1
+}
+
+// Manhattan is the number of steps from a to b on an empty grid, the
+// heuristic for AStar on a GridGraph.
+func Manhattan(a, b Point) int {
+	return abs(a.X-b.X) + abs(a.Y-b.Y)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// JPS finds a shortest path from start to goal on a GridGraph by jump point
+// search, which is A* that skips along straight runs of open cells instead of
+// queueing every cell on them. The path returned has every cell on it, as the
+// other searches' paths do, and is nil if goal can't be reached.
+//
+// Of the many equally short paths between two cells, only the ones that go
+// as far as they can across before going down or up are searched: a step
+// across may follow one down or up only where a wall forced it.
+func JPS(grid Grid, start, goal Point) []Point {
+	if !grid.Walkable(start) || !grid.Walkable(goal) {
+		return nil
+	}
+	j := jumper{grid: grid, goal: goal}
+	// Jump points are searched together with the direction they were
+	// reached in, which decides where the search goes from them.
+	first := jumpPoint{Point: start}
+	cameFrom := map[jumpPoint]jumpPoint{first: first}
+	costSoFar := map[jumpPoint]int{first: 0}
+	frontier := NewHeap[jumpPoint]()
+	frontier.Push(first, Manhattan(start, goal))
+
+	for frontier.Len() > 0 {
+		current, _ := frontier.Pop()
+		if current.Point == goal {
+			jumps := walkBack(cameFrom, first, current)
+			points := make([]Point, len(jumps))
+			for i, jp := range jumps {
+				points[i] = jp.Point
+			}
+			return fillPath(points)
+		}
+		for _, d := range j.successors(current) {
+			p, found := j.jump(current.Point, d)
+			if !found {
+				continue
+			}
+			next := jumpPoint{Point: p, dir: d}
+			newCost := costSoFar[current] + Manhattan(current.Point, p)
+			cost, seen := costSoFar[next]
+			if !seen || newCost < cost {
+				costSoFar[next] = newCost
+				cameFrom[next] = current
+				frontier.Push(next, newCost+Manhattan(p, goal))
+			}
+		}
+	}
+	return nil
+}
+
+// jumpPoint is a cell the search stopped at, reached going in direction dir,
+// which is zero for the start.
+type jumpPoint struct {
+	Point
+	dir Point
+}
+
+type jumper struct {
+	grid Grid
+	goal Point
+}
+
+// successors are the directions worth searching from jp.
+func (j *jumper) successors(jp jumpPoint) []Point {
+	p, d := jp.Point, jp.dir
+	if d == (Point{}) {
+		return directions
+	}
+	if d.Y == 0 {
+		return []Point{d, {0, -1}, {0, 1}}
+	}
+	dirs := []Point{d}
+	for _, side := range []Point{{-1, 0}, {1, 0}} {
+		if j.forced(p, d, side) {
+			dirs = append(dirs, side)
+		}
+	}
+	return dirs
+}
+
+// forced reports whether, having come to p going down or up in direction d,
+// the step across to the side has to be taken from p, because the cell to
+// that side of the one before p is blocked.
+func (j *jumper) forced(p, d, side Point) bool {
+	return j.grid.Walkable(Point{p.X + side.X, p.Y + side.Y}) && !j.grid.Walkable(Point{p.X + side.X, p.Y - d.Y})
+}
+
+// jump goes from p in direction d until it reaches the goal or a cell the
+// search has to turn at, and returns it. It returns false if it runs into a
+// wall first.
+func (j *jumper) jump(p, d Point) (Point, bool) {
+	for {
+		p = Point{p.X + d.X, p.Y + d.Y}
+		if !j.grid.Walkable(p) {
+			return Point{}, false
+		}
+		if p == j.goal {
+			return p, true
+		}
+		if d.Y != 0 {
+			if j.forced(p, d, Point{-1, 0}) || j.forced(p, d, Point{1, 0}) {
+				return p, true
+			}
+			continue
+		}
+		// Going across, p is worth stopping at if going down or up from it
+		// gets anywhere.
+		for _, vertical := range []Point{{0, -1}, {0, 1}} {
+			_, found := j.jump(p, vertical)
+			if found {
+				return p, true
+			}
+		}
+	}
+}
+
+// fillPath puts back the cells between the jump points of a path.
+func fillPath(jumps []Point) []Point {
+	path := []Point{jumps[0]}
+	for i := 1; i < len(jumps); i++ {
+		from, to := jumps[i-1], jumps[i]
+		step := Point{sign(to.X - from.X), sign(to.Y - from.Y)}
+		for p := from; p != to; {
+			p = Point{p.X + step.X, p.Y + step.Y}
+			path = append(path, p)
+		}
+	}
+	return path
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,122 @@
+package pathfind
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// testGrid is a grid of w by h cells, true where they can be walked through.
+type testGrid struct {
+	w, h  int
+	cells []bool
+}
+
+func (g testGrid) Walkable(p Point) bool {
+	return p.X >= 0 && p.Y >= 0 && p.X < g.w && p.Y < g.h && g.cells[g.node(p)]
+}
+
+func (g testGrid) node(p Point) int {
+	return p.Y*g.w + p.X
+}
+
+func (g testGrid) randomOpen(r *rand.Rand) Point {
+	open := make([]Point, 0)
+	for y := 0; y < g.h; y++ {
+		for x := 0; x < g.w; x++ {
+			if g.Walkable(Point{x, y}) {
+				open = append(open, Point{x, y})
+			}
+		}
+	}
+	return open[r.Intn(len(open))]
+}
+
+func (g testGrid) String() string {
+	var b strings.Builder
+	for y := 0; y < g.h; y++ {
+		for x := 0; x < g.w; x++ {
+			if g.Walkable(Point{x, y}) {
+				b.WriteByte('.')
+			} else {
+				b.WriteByte('#')
+			}
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func openGrid(w, h int) testGrid {
+	g := testGrid{w: w, h: h, cells: make([]bool, w*h)}
+	for i := range g.cells {
+		g.cells[i] = true
+	}
+	return g
+}
+
+// randomGrid blocks each cell with probability blocked, always leaving at
+// least one open.
+func randomGrid(r *rand.Rand, w, h int, blocked float64) testGrid {
+	g := openGrid(w, h)
+	for i := range g.cells {
+		g.cells[i] = r.Float64() >= blocked
+	}
+	g.cells[r.Intn(len(g.cells))] = true
+	return g
+}
+
+// gridNodes is a testGrid numbered as a Graph[int] for bruteForce.
+type gridNodes struct {
+	testGrid
+}
+
+func (g gridNodes) Neighbors(n int) []int {
+	p := Point{n % g.w, n / g.w}
+	if !g.Walkable(p) {
+		return nil
+	}
+	neighbors := make([]int, 0, 4)
+	for _, next := range (GridGraph{g.testGrid}).Neighbors(p) {
+		neighbors = append(neighbors, g.node(next))
+	}
+	return neighbors
+}
+
+func (g gridNodes) Cost(from, to int) int {
+	return 1
+}
+
+func TestJPSAgainstBruteForce(t *testing.T) {
+	r := rand.New(rand.NewSource(4))
+	for round := 0; round < 2000; round++ {
+		grid := randomGrid(r, 1+r.Intn(16), 1+r.Intn(16), r.Float64()*0.5)
+		start, goal := grid.randomOpen(r), grid.randomOpen(r)
+		want := bruteForce(gridNodes{grid}, grid.w*grid.h, grid.node(start))[grid.node(goal)]
+
+		path := JPS(grid, start, goal)
+		err := checkPath[Point](GridGraph{grid}, path, start, goal, want)
+		if err != nil {
+			t.Fatalf("%v on\n%v", err, grid)
+		}
+	}
+}
+
+func TestJPSOpenGrid(t *testing.T) {
+	grid := openGrid(50, 50)
+	path := JPS(grid, Point{0, 0}, Point{49, 49})
+	if len(path) != 99 {
+		t.Errorf("path has %d cells, want 99", len(path))
+	}
+}
+
+func TestJPSBlockedEnds(t *testing.T) {
+	grid := openGrid(3, 1)
+	grid.cells[2] = false
+	if path := JPS(grid, Point{0, 0}, Point{2, 0}); path != nil {
+		t.Errorf("found path %v to a wall", path)
+	}
+	if path := JPS(grid, Point{0, 0}, Point{0, 0}); len(path) != 1 {
+		t.Errorf("path %v from a cell to itself, want just the cell", path)
+	}
+}
@@ -0,0 +1,100 @@
+// Package pathfind finds shortest paths. Searches run over anything that
+// implements Graph; grids of walkable and blocked cells have their own
+// jump point search, JPS.
+package pathfind
+
+// Heap is a priority queue of distinct items, lowest priority first. Pushing
+// an item that is already queued changes its priority, which is how searches
+// lower the cost of a node they found a better way to.
+type Heap[T comparable] struct {
+	items []heapItem[T]
+	index map[T]int
+}
+
+type heapItem[T comparable] struct {
+	item     T
+	priority int
+}
+
+func NewHeap[T comparable]() *Heap[T] {
+	return &Heap[T]{index: make(map[T]int)}
+}
+
+func (h *Heap[T]) Len() int {
+	return len(h.items)
+}
+
+// Priority returns the priority of item and whether it is queued.
+func (h *Heap[T]) Priority(item T) (int, bool) {
+	i, exists := h.index[item]
+	if !exists {
+		return 0, false
+	}
+	return h.items[i].priority, true
+}
+
+// Push queues item with the given priority, or gives it that priority if it
+// is queued already.
+func (h *Heap[T]) Push(item T, priority int) {
+	i, exists := h.index[item]
+	if exists {
+		old := h.items[i].priority
+		h.items[i].priority = priority
+		if priority < old {
+			h.up(i)
+		} else {
+			h.down(i)
+		}
+		return
+	}
+	h.items = append(h.items, heapItem[T]{item: item, priority: priority})
+	h.index[item] = len(h.items) - 1
+	h.up(len(h.items) - 1)
+}
+
+// Pop removes the item with the lowest priority and returns it. It panics if
+// the heap is empty.
+func (h *Heap[T]) Pop() (T, int) {
+	top := h.items[0]
+	last := len(h.items) - 1
+	h.swap(0, last)
+	h.items = h.items[:last]
+	delete(h.index, top.item)
+	if last > 0 {
+		h.down(0)
+	}
+	return top.item, top.priority
+}
+
+func (h *Heap[T]) up(i int) {
+	for i > 0 {
+		parent := (i - 1) / 2
+		if h.items[parent].priority <= h.items[i].priority {
+			return
+		}
+		h.swap(i, parent)
+		i = parent
+	}
+}
+
+func (h *Heap[T]) down(i int) {
+	for {
+		smallest := i
+		for _, child := range []int{2*i + 1, 2*i + 2} {
+			if child < len(h.items) && h.items[child].priority < h.items[smallest].priority {
+				smallest = child
+			}
+		}
+		if smallest == i {
+			return
+		}
+		h.swap(i, smallest)
+		i = smallest
+	}
+}
+
+func (h *Heap[T]) swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+	h.index[h.items[i].item] = i
+	h.index[h.items[j].item] = j
+}
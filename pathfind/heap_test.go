@@ -0,0 +1,67 @@
+package pathfind
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestHeapPopsInOrder(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for n := 1; n < 100; n++ {
+		h := NewHeap[int]()
+		priorities := make([]int, n)
+		for i := range priorities {
+			priorities[i] = r.Intn(50)
+			h.Push(i, priorities[i])
+		}
+		sorted := append([]int(nil), priorities...)
+		sort.Ints(sorted)
+
+		popped := make(map[int]bool)
+		for i, want := range sorted {
+			item, priority := h.Pop()
+			if popped[item] {
+				t.Fatalf("n=%d: %d popped twice", n, item)
+			}
+			popped[item] = true
+			if priority != want || priorities[item] != want {
+				t.Fatalf("n=%d: pop %d gave %d at %d, want priority %d", n, i, item, priority, want)
+			}
+		}
+		if h.Len() != 0 {
+			t.Errorf("n=%d: %d left", n, h.Len())
+		}
+	}
+}
+
+func TestHeapChangePriority(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	for round := 0; round < 100; round++ {
+		h := NewHeap[int]()
+		want := make(map[int]int)
+		for i := 0; i < 200; i++ {
+			item := r.Intn(30)
+			priority := r.Intn(100)
+			h.Push(item, priority)
+			want[item] = priority
+		}
+		if h.Len() != len(want) {
+			t.Fatalf("heap has %d items, want %d", h.Len(), len(want))
+		}
+		for item, priority := range want {
+			got, queued := h.Priority(item)
+			if !queued || got != priority {
+				t.Fatalf("item %d has priority %d, %v, want %d", item, got, queued, priority)
+			}
+		}
+		last := -1
+		for h.Len() > 0 {
+			item, priority := h.Pop()
+			if priority < last || priority != want[item] {
+				t.Fatalf("popped %d at %d after %d, want priority %d", item, priority, last, want[item])
+			}
+			last = priority
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package pathfind
+
+// Graph is what the searches find paths through. Neighbors lists the nodes
+// one step from n, and Cost is what the step from one to the other costs,
+// which must not be negative.
+type Graph[N comparable] interface {
+	Neighbors(n N) []N
+	Cost(from, to N) int
+}
+
+// BFS finds the path with the fewest steps from start to the nearest node
+// for which found is true, ignoring costs. Paths run from start to the node
+// found, both included, and are nil when there is no such node.
+func BFS[N comparable](g Graph[N], start N, found func(N) bool) []N {
+	cameFrom := map[N]N{start: start}
+	frontier := []N{start}
+	for len(frontier) > 0 {
+		current := frontier[0]
+		frontier = frontier[1:]
+		if found(current) {
+			return walkBack(cameFrom, start, current)
+		}
+		for _, next := range g.Neighbors(current) {
+			_, visited := cameFrom[next]
+			if !visited {
+				cameFrom[next] = current
+				frontier = append(frontier, next)
+			}
+		}
+	}
+	return nil
+}
+
+// Dijkstra finds the cheapest path from start to goal.
+func Dijkstra[N comparable](g Graph[N], start, goal N) []N {
+	return AStar(g, start, goal, func(N) int { return 0 })
+}
+
+// AStar finds the cheapest path from start to goal, guided by h, which
+// guesses the cost from a node to goal. The path is only sure to be the
+// cheapest if h never guesses high.
+func AStar[N comparable](g Graph[N], start, goal N, h func(N) int) []N {
+	cameFrom := map[N]N{start: start}
+	costSoFar := map[N]int{start: 0}
+	frontier := NewHeap[N]()
+	frontier.Push(start, h(start))
+
+	for frontier.Len() > 0 {
+		current, _ := frontier.Pop()
+		if current == goal {
+			return walkBack(cameFrom, start, goal)
+		}
+
+		for _, next := range g.Neighbors(current) {
+			newCost := costSoFar[current] + g.Cost(current, next)
+			cost, seen := costSoFar[next]
+			if !seen || newCost < cost {
+				costSoFar[next] = newCost
+				cameFrom[next] = current
+				frontier.Push(next, newCost+h(next))
+			}
+		}
+	}
+	return nil
+}
+
+// Distances is the cost of the cheapest path from start to every node that
+// can be reached from it.
+func Distances[N comparable](g Graph[N], start N) map[N]int {
+	dist := map[N]int{start: 0}
+	frontier := NewHeap[N]()
+	frontier.Push(start, 0)
+	for frontier.Len() > 0 {
+		current, cost := frontier.Pop()
+		for _, next := range g.Neighbors(current) {
+			newCost := cost + g.Cost(current, next)
+			old, seen := dist[next]
+			if !seen || newCost < old {
+				dist[next] = newCost
+				frontier.Push(next, newCost)
+			}
+		}
+	}
+	return dist
+}
+
+// PathCost is what walking path through g costs.
+func PathCost[N comparable](g Graph[N], path []N) int {
+	cost := 0
+	for i := 1; i < len(path); i++ {
+		cost += g.Cost(path[i-1], path[i])
+	}
+	return cost
+}
+
+func walkBack[N comparable](cameFrom map[N]N, start, end N) []N {
+	path := []N{end}
+	for n := end; n != start; {
+		n = cameFrom[n]
+		path = append(path, n)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
@@ -0,0 +1,166 @@
+package pathfind
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// weighted is a random directed graph with costed edges.
+type weighted struct {
+	edges []map[int]int
+}
+
+func (g weighted) Neighbors(n int) []int {
+	neighbors := make([]int, 0, len(g.edges[n]))
+	for next := range g.edges[n] {
+		neighbors = append(neighbors, next)
+	}
+	return neighbors
+}
+
+func (g weighted) Cost(from, to int) int {
+	return g.edges[from][to]
+}
+
+func randomGraph(r *rand.Rand, nodes, edges, maxCost int) weighted {
+	g := weighted{edges: make([]map[int]int, nodes)}
+	for i := range g.edges {
+		g.edges[i] = make(map[int]int)
+	}
+	for i := 0; i < edges; i++ {
+		g.edges[r.Intn(nodes)][r.Intn(nodes)] = r.Intn(maxCost + 1)
+	}
+	return g
+}
+
+// bruteForce is the cost of the cheapest path from start to every node, by
+// relaxing every edge until nothing changes, or -1 for nodes out of reach.
+func bruteForce(g Graph[int], nodes, start int) []int {
+	dist := make([]int, nodes)
+	for i := range dist {
+		dist[i] = -1
+	}
+	dist[start] = 0
+	for changed := true; changed; {
+		changed = false
+		for n := 0; n < nodes; n++ {
+			if dist[n] < 0 {
+				continue
+			}
+			for _, next := range g.Neighbors(n) {
+				cost := dist[n] + g.Cost(n, next)
+				if dist[next] < 0 || cost < dist[next] {
+					dist[next] = cost
+					changed = true
+				}
+			}
+		}
+	}
+	return dist
+}
+
+// checkPath checks that path is a real path from start to goal through g,
+// costing want, or nil if want is -1.
+func checkPath[N comparable](g Graph[N], path []N, start, goal N, want int) error {
+	if want < 0 {
+		if path != nil {
+			return fmt.Errorf("found path %v from %v to %v, which is out of reach", path, start, goal)
+		}
+		return nil
+	}
+	if len(path) == 0 || path[0] != start || path[len(path)-1] != goal {
+		return fmt.Errorf("path %v doesn't run from %v to %v", path, start, goal)
+	}
+	for i := 1; i < len(path); i++ {
+		found := false
+		for _, next := range g.Neighbors(path[i-1]) {
+			if next == path[i] {
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("path %v steps from %v to %v, which aren't neighbours", path, path[i-1], path[i])
+		}
+	}
+	if cost := PathCost(g, path); cost != want {
+		return fmt.Errorf("path %v from %v to %v costs %d, want %d", path, start, goal, cost, want)
+	}
+	return nil
+}
+
+func TestDijkstraAgainstBruteForce(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for round := 0; round < 300; round++ {
+		nodes := 1 + r.Intn(20)
+		g := randomGraph(r, nodes, r.Intn(nodes*4), 9)
+		start := r.Intn(nodes)
+		want := bruteForce(g, nodes, start)
+
+		dist := Distances[int](g, start)
+		for goal := 0; goal < nodes; goal++ {
+			err := checkPath[int](g, Dijkstra[int](g, start, goal), start, goal, want[goal])
+			if err != nil {
+				t.Fatal(err)
+			}
+			d, reached := dist[goal]
+			if !reached {
+				d = -1
+			}
+			if d != want[goal] {
+				t.Fatalf("Distances gave %d from %d to %d, want %d", d, start, goal, want[goal])
+			}
+		}
+	}
+}
+
+// unit is a graph with every edge costing 1.
+type unit struct {
+	weighted
+}
+
+func (g unit) Cost(from, to int) int {
+	return 1
+}
+
+func TestBFSAgainstBruteForce(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	for round := 0; round < 300; round++ {
+		nodes := 1 + r.Intn(20)
+		g := unit{randomGraph(r, nodes, r.Intn(nodes*3), 1)}
+		start := r.Intn(nodes)
+		want := bruteForce(g, nodes, start)
+
+		for goal := 0; goal < nodes; goal++ {
+			path := BFS[int](g, start, func(n int) bool { return n == goal })
+			err := checkPath[int](g, path, start, goal, want[goal])
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestBFSFindsNearest(t *testing.T) {
+	g := GridGraph{openGrid(10, 1)}
+	path := BFS[Point](g, Point{5, 0}, func(p Point) bool { return p.X == 0 || p.X == 7 })
+	if len(path) != 3 || path[2] != (Point{7, 0}) {
+		t.Errorf("path %v, want 3 cells to 7,0", path)
+	}
+}
+
+func TestAStarAgainstBruteForce(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	for round := 0; round < 300; round++ {
+		grid := randomGrid(r, 1+r.Intn(12), 1+r.Intn(12), 0.3)
+		g := GridGraph{grid}
+		start, goal := grid.randomOpen(r), grid.randomOpen(r)
+		want := bruteForce(gridNodes{grid}, grid.w*grid.h, grid.node(start))[grid.node(goal)]
+
+		path := AStar[Point](g, start, goal, func(p Point) int { return Manhattan(p, goal) })
+		err := checkPath[Point](g, path, start, goal, want)
+		if err != nil {
+			t.Fatalf("%v on\n%v", err, grid)
+		}
+	}
+}
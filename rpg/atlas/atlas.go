@@ -0,0 +1,245 @@
+// Package atlas reads and writes the descriptor that says where the rpg's
+// sprites are in its texture atlas images.
+//
+// The descriptor is a JSON file, atlas.json, listing the images and the
+// sprites cut from them. Each sprite has one or more weighted variants, one of
+// which is picked for every place the sprite is drawn, and each variant one
+// or more animation frames. Sprites drawn for a map character give it as
+// their rune.
+//
+// Before atlas.json there was atlas-index.txt, which has one line per rune,
+//
+//	rune column, row, variants[, frames]
+//
+// giving the 32 pixel tile of the first variant in tiles.png. The other
+// variants, and the frames of each variant, follow it on the same row,
+// wrapping to the next after column 62. ReadIndex converts it.
+package atlas
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultFrameTime is how long, in milliseconds, each frame of an animated
+// sprite is shown when the sprite doesn't say.
+const DefaultFrameTime = 200
+
+type Atlas struct {
+	// TileSize is the width and height of frames that don't give their own.
+	TileSize int `json:"tileSize"`
+	// Images maps the names sprites use for images to their files, relative
+	// to the descriptor.
+	Images  map[string]string `json:"images"`
+	Sprites []Sprite          `json:"sprites"`
+}
+
+type Sprite struct {
+	Name  string `json:"name"`
+	Rune  string `json:"rune,omitempty"`
+	Image string `json:"image"`
+	// FrameTime is how long each animation frame is shown, in milliseconds.
+	FrameTime int       `json:"frameTime,omitempty"`
+	Variants  []Variant `json:"variants"`
+}
+
+// Variant is one look of a sprite. Weight 0 counts as 1.
+type Variant struct {
+	Weight int    `json:"weight,omitempty"`
+	Frames []Rect `json:"frames"`
+}
+
+// Rect is a frame's position in its image, in pixels. A width or height of 0
+// means the atlas tile size.
+type Rect struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w,omitempty"`
+	H int `json:"h,omitempty"`
+}
+
+// RuneValue is the map character the sprite is drawn for, if any.
+func (s *Sprite) RuneValue() (rune, bool) {
+	if s.Rune == "" {
+		return 0, false
+	}
+	r, _ := utf8.DecodeRuneInString(s.Rune)
+	return r, true
+}
+
+// Read reads and checks the descriptor at path and fills in its defaults.
+func Read(path string) (*Atlas, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	a := &Atlas{}
+	err = json.NewDecoder(file).Decode(a)
+	if err != nil {
+		return nil, err
+	}
+	err = a.check()
+	if err != nil {
+		return nil, err
+	}
+	a.FillDefaults()
+	return a, nil
+}
+
+func (a *Atlas) check() error {
+	if len(a.Images) == 0 {
+		return errors.New("atlas has no images")
+	}
+	runes := make(map[string]string)
+	for _, s := range a.Sprites {
+		if s.Name == "" {
+			return errors.New("atlas has a sprite with no name")
+		}
+		if _, exists := a.Images[s.Image]; !exists {
+			return fmt.Errorf("sprite %s uses unknown image %q", s.Name, s.Image)
+		}
+		if s.Rune != "" {
+			if utf8.RuneCountInString(s.Rune) != 1 {
+				return fmt.Errorf("sprite %s has rune %q, which isn't one character", s.Name, s.Rune)
+			}
+			if other, exists := runes[s.Rune]; exists {
+				return fmt.Errorf("sprites %s and %s both have rune %q", other, s.Name, s.Rune)
+			}
+			runes[s.Rune] = s.Name
+		}
+		if len(s.Variants) == 0 {
+			return fmt.Errorf("sprite %s has no variants", s.Name)
+		}
+		for _, v := range s.Variants {
+			if len(v.Frames) == 0 {
+				return fmt.Errorf("sprite %s has a variant with no frames", s.Name)
+			}
+			if v.Weight < 0 {
+				return fmt.Errorf("sprite %s has a variant with negative weight", s.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// FillDefaults gives every frame its size, every variant its weight and every
+// sprite its frame time. Read does this itself; ReadIndex leaves it out so
+// that converted descriptors stay short.
+func (a *Atlas) FillDefaults() {
+	if a.TileSize == 0 {
+		a.TileSize = 32
+	}
+	for i := range a.Sprites {
+		s := &a.Sprites[i]
+		if s.FrameTime == 0 {
+			s.FrameTime = DefaultFrameTime
+		}
+		for j := range s.Variants {
+			v := &s.Variants[j]
+			if v.Weight == 0 {
+				v.Weight = 1
+			}
+			for k := range v.Frames {
+				f := &v.Frames[k]
+				if f.W == 0 {
+					f.W = a.TileSize
+				}
+				if f.H == 0 {
+					f.H = a.TileSize
+				}
+			}
+		}
+	}
+}
+
+func (a *Atlas) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(a)
+}
+
+// oldNames names the sprites of the runes the game knew when atlas-index.txt
+// was replaced.
+var oldNames = map[rune]string{
+	'#': "stone wall",
+	'.': "dirt floor",
+	'|': "closed door",
+	'/': "open door",
+	'u': "up stair",
+	'd': "down stair",
+	'@': "player",
+	'R': "rat",
+	'S': "spider",
+}
+
+// ReadIndex converts an old atlas-index.txt, whose tiles are in image.
+func ReadIndex(r io.Reader, image string) (*Atlas, error) {
+	const tileSize = 32
+	a := &Atlas{TileSize: tileSize, Images: map[string]string{"tiles": image}}
+
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		tileRune, size := utf8.DecodeRuneInString(line)
+		fields := strings.Split(line[size:], ",")
+		if len(fields) < 3 || len(fields) > 4 {
+			return nil, fmt.Errorf("line %d: want rune x, y, variants[, frames]", lineNum)
+		}
+		nums := make([]int, 4)
+		nums[3] = 1
+		for i, field := range fields {
+			n, err := strconv.Atoi(strings.TrimSpace(field))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", lineNum, err)
+			}
+			nums[i] = n
+		}
+		x, y, variationCount, frameCount := nums[0], nums[1], nums[2], nums[3]
+
+		name, exists := oldNames[tileRune]
+		if !exists {
+			name = "rune " + string(tileRune)
+		}
+		s := Sprite{Name: name, Rune: string(tileRune), Image: "tiles"}
+		if frameCount > 1 {
+			s.FrameTime = DefaultFrameTime
+		}
+		for i := 0; i < variationCount; i++ {
+			var v Variant
+			for f := 0; f < frameCount; f++ {
+				v.Frames = append(v.Frames, Rect{X: x * tileSize, Y: y * tileSize})
+				x++
+				if x > 62 {
+					x = 0
+					y++
+				}
+			}
+			s.Variants = append(s.Variants, v)
+		}
+		a.Sprites = append(a.Sprites, s)
+	}
+	err := scanner.Err()
+	if err != nil {
+		return nil, err
+	}
+	err = a.check()
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
@@ -0,0 +1,122 @@
+package atlas
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadIndexFramesWrap(t *testing.T) {
+	a, err := ReadIndex(strings.NewReader("R 61, 3, 2, 2\n"), "tiles.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Sprite{{Name: "rat", Rune: "R", Image: "tiles", FrameTime: DefaultFrameTime, Variants: []Variant{
+		{Frames: []Rect{{X: 61 * 32, Y: 3 * 32}, {X: 62 * 32, Y: 3 * 32}}},
+		{Frames: []Rect{{X: 0, Y: 4 * 32}, {X: 32, Y: 4 * 32}}},
+	}}}
+	if !reflect.DeepEqual(a.Sprites, want) {
+		t.Errorf("sprites %+v, want %+v", a.Sprites, want)
+	}
+}
+
+func TestReadIndexToJSON(t *testing.T) {
+	a, err := ReadIndex(strings.NewReader(". 42, 7, 1\nx 0, 0, 1\n"), "tiles.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	err = a.Write(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "tileSize": 32,
+  "images": {
+    "tiles": "tiles.png"
+  },
+  "sprites": [
+    {
+      "name": "dirt floor",
+      "rune": ".",
+      "image": "tiles",
+      "variants": [
+        {
+          "frames": [
+            {
+              "x": 1344,
+              "y": 224
+            }
+          ]
+        }
+      ]
+    },
+    {
+      "name": "rune x",
+      "rune": "x",
+      "image": "tiles",
+      "variants": [
+        {
+          "frames": [
+            {
+              "x": 0,
+              "y": 0
+            }
+          ]
+        }
+      ]
+    }
+  ]
+}
+`
+	if buf.String() != want {
+		t.Errorf("wrote\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	for _, bad := range []string{
+		`{"sprites": []}`,
+		`{"images": {"tiles": "t.png"}, "sprites": [{"image": "tiles", "variants": [{"frames": [{}]}]}]}`,
+		`{"images": {"tiles": "t.png"}, "sprites": [{"name": "a", "image": "other", "variants": [{"frames": [{}]}]}]}`,
+		`{"images": {"tiles": "t.png"}, "sprites": [{"name": "a", "rune": "ab", "image": "tiles", "variants": [{"frames": [{}]}]}]}`,
+		`{"images": {"tiles": "t.png"}, "sprites": [{"name": "a", "rune": "a", "image": "tiles", "variants": [{"frames": [{}]}]}, {"name": "b", "rune": "a", "image": "tiles", "variants": [{"frames": [{}]}]}]}`,
+		`{"images": {"tiles": "t.png"}, "sprites": [{"name": "a", "image": "tiles"}]}`,
+		`{"images": {"tiles": "t.png"}, "sprites": [{"name": "a", "image": "tiles", "variants": [{"frames": []}]}]}`,
+		`{"images": {"tiles": "t.png"}, "sprites": [{"name": "a", "image": "tiles", "variants": [{"weight": -1, "frames": [{}]}]}]}`,
+	} {
+		a := &Atlas{}
+		err := json.Unmarshal([]byte(bad), a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a.check() == nil {
+			t.Errorf("accepted %s", bad)
+		}
+	}
+}
+
+// The shipped atlas must read, and have an animated sprite, so that the
+// animation path is exercised.
+func TestShippedAtlas(t *testing.T) {
+	a, err := Read("../ui2d/assets/atlas.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	animated := false
+	for _, s := range a.Sprites {
+		for _, v := range s.Variants {
+			if len(v.Frames) > 1 {
+				animated = true
+			}
+			if v.Weight == 0 || v.Frames[0].W == 0 {
+				t.Errorf("sprite %s has defaults left unfilled", s.Name)
+			}
+		}
+	}
+	if !animated {
+		t.Errorf("no sprite in the atlas is animated")
+	}
+}
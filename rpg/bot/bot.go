@@ -0,0 +1,155 @@
+// Package bot plays the rpg by itself, the way a player at a UI would: it
+// reads the snapshots the game publishes and sends inputs back. It explores
+// what it hasn't seen, fights monsters while it is healthy and runs from
+// them when it isn't, and takes portals on to levels it hasn't finished.
+// Playing many seeded games and comparing their Stats shows how hard a level
+// is to get through.
+package bot
+
+import (
+	"fmt"
+
+	"github.com/LucasK1/gameswithgo/rpg/game"
+)
+
+// Stats is how one game went. Finished is whether the bot ran out of places
+// to explore before it ran out of turns, and Unexplored the fraction of floor
+// on all levels it never saw.
+type Stats struct {
+	Seed        int64
+	Turns       int
+	Finished    bool
+	Died        bool
+	DamageTaken int
+	DamageDealt int
+	Kills       map[string]int
+	Levels      int
+	Deepest     int
+	Unexplored  float64
+}
+
+// Play plays a game from seed for at most maxTurns turns.
+func Play(seed int64, maxTurns int) Stats {
+	g := game.NewPlaytestGame(seed)
+	stats := Stats{Seed: seed}
+
+	ended := make(chan bool)
+	go func() {
+		g.Run()
+		close(ended)
+	}()
+
+	b := newBrain(seed)
+	visited := make(map[string]bool)
+	for {
+		select {
+		case <-ended:
+			stats.Turns = g.Turn
+			stats.Died = g.Stats.Died
+			stats.DamageTaken = g.Stats.DamageTaken
+			stats.DamageDealt = g.Stats.DamageDealt
+			stats.Kills = g.Stats.Kills
+			stats.Deepest = g.Stats.Deepest
+			stats.Unexplored = unexplored(g)
+			return stats
+
+		case snap := <-g.LevelChans[0]:
+			if !visited[snap.Name] {
+				visited[snap.Name] = true
+				stats.Levels++
+			}
+
+			input := b.decide(snap)
+			if input == nil {
+				stats.Finished = true
+				input = &game.Input{Type: game.QuitGame}
+			} else if g.Turn >= maxTurns {
+				input = &game.Input{Type: game.QuitGame}
+			}
+			g.InputChan <- input
+		}
+	}
+}
+
+// unexplored is the fraction of walkable tiles in the game that the player
+// never saw. It must only be called once the game has stopped running.
+func unexplored(g *game.Game) float64 {
+	total, unseen := 0, 0
+	for _, level := range g.Levels {
+		for _, row := range level.Map {
+			for _, tile := range row {
+				if tile.Rune == game.StoneWall || tile.Rune == game.Blank {
+					continue
+				}
+				total++
+				if !tile.Seen {
+					unseen++
+				}
+			}
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(unseen) / float64(total)
+}
+
+// Report sums up the Stats of many games.
+type Report struct {
+	Runs     int
+	Finished int
+	Deaths   int
+	// MeanTurns is the mean number of turns the finished games took.
+	MeanTurns       float64
+	MeanDamageTaken float64
+	MeanDamageDealt float64
+	MeanUnexplored  float64
+	Kills           map[string]int
+}
+
+func Summarize(runs []Stats) Report {
+	r := Report{Runs: len(runs), Kills: make(map[string]int)}
+	if len(runs) == 0 {
+		return r
+	}
+	finishedTurns := 0
+	for _, s := range runs {
+		if s.Finished {
+			r.Finished++
+			finishedTurns += s.Turns
+		}
+		if s.Died {
+			r.Deaths++
+		}
+		r.MeanDamageTaken += float64(s.DamageTaken)
+		r.MeanDamageDealt += float64(s.DamageDealt)
+		r.MeanUnexplored += s.Unexplored
+		for monster, n := range s.Kills {
+			r.Kills[monster] += n
+		}
+	}
+	n := float64(len(runs))
+	r.MeanDamageTaken /= n
+	r.MeanDamageDealt /= n
+	r.MeanUnexplored /= n
+	if r.Finished > 0 {
+		r.MeanTurns = float64(finishedTurns) / float64(r.Finished)
+	}
+	return r
+}
+
+func (s Stats) String() string {
+	outcome := "out of turns"
+	if s.Died {
+		outcome = "died"
+	} else if s.Finished {
+		outcome = "finished"
+	}
+	return fmt.Sprintf("seed %d: %s after %d turns, took %d damage, dealt %d, killed %v, %d levels to depth %d, %.0f%% unexplored",
+		s.Seed, outcome, s.Turns, s.DamageTaken, s.DamageDealt, s.Kills, s.Levels, s.Deepest, 100*s.Unexplored)
+}
+
+func (r Report) String() string {
+	return fmt.Sprintf("%d runs: %d finished in %.1f turns on average, %d died; %.1f damage taken and %.1f dealt on average; kills %v; %.0f%% unexplored on average",
+		r.Runs, r.Finished, r.MeanTurns, r.Deaths, r.MeanDamageTaken, r.MeanDamageDealt, r.Kills, 100*r.MeanUnexplored)
+}
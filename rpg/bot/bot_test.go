@@ -0,0 +1,34 @@
+package bot
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPlayExploresTheWorld(t *testing.T) {
+	s := Play(1, 2000)
+	if !s.Finished || s.Died {
+		t.Fatalf("bot didn't get through the game: %v", s)
+	}
+	if s.Levels < 2 || s.Unexplored > 0.1 {
+		t.Errorf("bot left too much unexplored: %v", s)
+	}
+}
+
+func TestPlayIsRepeatable(t *testing.T) {
+	a, b := Play(7, 300), Play(7, 300)
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("the same seed played out differently:\n%v\n%v", a, b)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	r := Summarize([]Stats{
+		{Turns: 100, Finished: true, DamageTaken: 4, Kills: map[string]int{"Rat": 1}, Unexplored: 0.5},
+		{Turns: 50, Died: true, DamageTaken: 20, Kills: map[string]int{"Rat": 2, "Spider": 1}},
+	})
+	want := Report{Runs: 2, Finished: 1, Deaths: 1, MeanTurns: 100, MeanDamageTaken: 12, MeanUnexplored: 0.25, Kills: map[string]int{"Rat": 3, "Spider": 1}}
+	if !reflect.DeepEqual(r, want) {
+		t.Errorf("got %+v, want %+v", r, want)
+	}
+}
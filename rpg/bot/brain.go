@@ -0,0 +1,299 @@
+package bot
+
+import (
+	"math/rand"
+	"sort"
+
+	"github.com/LucasK1/gameswithgo/pathfind"
+	"github.com/LucasK1/gameswithgo/rpg/game"
+)
+
+// huntRange is how far away a monster can be for the bot to go after it.
+const huntRange = 5
+
+// brain decides what the bot does next. It remembers which portals barred its
+// way and which levels it has explored all of, and picks between equally
+// good moves with its own seeded rand.
+type brain struct {
+	rand   *rand.Rand
+	maxHP  int
+	barred map[levelPos]bool
+	done   map[string]bool
+	// portals holds where the portals the bot has seen lead, by level.
+	portals map[string]map[game.Pos]game.PortalDest
+	last    *game.Input
+	lastPos levelPos
+	// stood is everywhere the bot has been, which it needn't go back to to
+	// look around.
+	stood map[levelPos]bool
+}
+
+type levelPos struct {
+	level string
+	pos   game.Pos
+}
+
+func newBrain(seed int64) *brain {
+	return &brain{
+		rand:    rand.New(rand.NewSource(seed)),
+		barred:  make(map[levelPos]bool),
+		done:    make(map[string]bool),
+		portals: make(map[string]map[game.Pos]game.PortalDest),
+		stood:   make(map[levelPos]bool),
+	}
+}
+
+var moves = []struct {
+	game.Pos
+	input game.InputType
+}{
+	{game.Pos{X: 0, Y: -1}, game.Up},
+	{game.Pos{X: 0, Y: 1}, game.Down},
+	{game.Pos{X: -1, Y: 0}, game.Left},
+	{game.Pos{X: 1, Y: 0}, game.Right},
+}
+
+// decide picks the input for the turn shown in snap, or returns nil when
+// there is nowhere left to go.
+func (b *brain) decide(snap *game.Snapshot) *game.Input {
+	if snap.Dialogue != nil || snap.Shop != nil {
+		return &game.Input{Type: game.Choose, Choice: -1}
+	}
+	p := snap.Player.Pos
+	here := levelPos{snap.Name, p}
+	if snap.Player.HP > b.maxHP {
+		b.maxHP = snap.Player.HP
+	}
+	// A portal that left the bot where it was wants something it hasn't got.
+	if b.last != nil && here == b.lastPos {
+		target := step(p, b.last.Type)
+		_, isPortal := snap.Portals[target]
+		if isPortal {
+			b.barred[levelPos{snap.Name, target}] = true
+		}
+	}
+	b.portals[snap.Name] = snap.Portals
+	b.stood[here] = true
+
+	input := b.choose(snap)
+	b.last = input
+	b.lastPos = here
+	return input
+}
+
+func (b *brain) choose(snap *game.Snapshot) *game.Input {
+	p := snap.Player.Pos
+	m := known{snap: snap, barred: b.barred, stood: b.stood}
+
+	monsters := make([]game.Pos, 0)
+	for pos := range snap.Monsters {
+		if snap.Map[pos.Y][pos.X].Visible {
+			monsters = append(monsters, pos)
+		}
+	}
+	sortPositions(monsters)
+	healthy := snap.Player.HP*3 > b.maxHP
+
+	if len(monsters) > 0 && !healthy {
+		input := b.flee(m, p, monsters)
+		if input != nil {
+			return input
+		}
+	}
+	for _, pos := range monsters {
+		if pathfind.Manhattan(point(p), point(pos)) == 1 {
+			return move(p, pos)
+		}
+	}
+	if healthy {
+		hunt := m
+		hunt.monsters = true
+		path := pathfind.BFS[game.Pos](hunt, p, func(pos game.Pos) bool {
+			_, exists := snap.Monsters[pos]
+			return exists && snap.Map[pos.Y][pos.X].Visible
+		})
+		if path != nil && len(path)-1 <= huntRange {
+			return move(p, path[1])
+		}
+	}
+
+	// Go and look at whatever hasn't been seen yet.
+	path := pathfind.BFS[game.Pos](m, p, m.frontier)
+	if path != nil {
+		return move(p, path[1])
+	}
+	b.done[snap.Name] = true
+
+	dest := b.nextLevel(snap.Name)
+	if dest == "" {
+		return nil
+	}
+	m.portalsTo = dest
+	path = pathfind.BFS[game.Pos](m, p, func(pos game.Pos) bool {
+		d, isPortal := snap.Portals[pos]
+		return isPortal && d.Level == dest && !b.barred[levelPos{snap.Name, pos}]
+	})
+	if path == nil || len(path) < 2 {
+		return nil
+	}
+	return move(p, path[1])
+}
+
+// flee steps to the neighbouring tile furthest from the nearest monster, if
+// that is further than the bot is now.
+func (b *brain) flee(m known, p game.Pos, monsters []game.Pos) *game.Input {
+	nearest := func(pos game.Pos) int {
+		best := -1
+		for _, monster := range monsters {
+			d := pathfind.Manhattan(point(pos), point(monster))
+			if best < 0 || d < best {
+				best = d
+			}
+		}
+		return best
+	}
+	best := nearest(p)
+	var to *game.Pos
+	for _, next := range m.Neighbors(p) {
+		d := nearest(next)
+		if d > best || d == best && to != nil && b.rand.Intn(2) == 0 {
+			best = d
+			n := next
+			to = &n
+		}
+	}
+	if to == nil {
+		return nil
+	}
+	return move(p, *to)
+}
+
+// nextLevel is the level to head for, through the fewest portals, which the
+// bot hasn't explored all of yet, or "" if there isn't one.
+func (b *brain) nextLevel(from string) string {
+	firstHop := map[string]string{from: ""}
+	frontier := []string{from}
+	for len(frontier) > 0 {
+		current := frontier[0]
+		frontier = frontier[1:]
+		positions := make([]game.Pos, 0, len(b.portals[current]))
+		for pos := range b.portals[current] {
+			positions = append(positions, pos)
+		}
+		sortPositions(positions)
+		for _, pos := range positions {
+			dest := b.portals[current][pos]
+			_, seen := firstHop[dest.Level]
+			if seen || b.barred[levelPos{current, pos}] {
+				continue
+			}
+			hop := firstHop[current]
+			if current == from {
+				hop = dest.Level
+			}
+			if !b.done[dest.Level] {
+				return hop
+			}
+			firstHop[dest.Level] = hop
+			frontier = append(frontier, dest.Level)
+		}
+	}
+	return ""
+}
+
+// known is the map as far as the bot knows it, as a graph of the tiles it can
+// walk to. Closed doors count, as walking into one opens it, but NPCs and
+// portals don't, except for the portals to portalsTo, and monsters only do
+// when it is out hunting them.
+type known struct {
+	snap      *game.Snapshot
+	barred    map[levelPos]bool
+	stood     map[levelPos]bool
+	portalsTo string
+	monsters  bool
+}
+
+func (m known) walkable(pos game.Pos) bool {
+	snap := m.snap
+	if pos.Y < 0 || pos.Y >= len(snap.Map) || pos.X < 0 || pos.X >= len(snap.Map[pos.Y]) {
+		return false
+	}
+	tile := snap.Map[pos.Y][pos.X]
+	if !tile.Seen || tile.Rune == game.StoneWall || tile.Rune == game.Blank {
+		return false
+	}
+	_, isMonster := snap.Monsters[pos]
+	_, isNPC := snap.NPCs[pos]
+	if isMonster && !m.monsters || isNPC {
+		return false
+	}
+	dest, isPortal := snap.Portals[pos]
+	if isPortal {
+		return dest.Level == m.portalsTo && !m.barred[levelPos{snap.Name, pos}]
+	}
+	return true
+}
+
+func (m known) Neighbors(pos game.Pos) []game.Pos {
+	neighbors := make([]game.Pos, 0, 4)
+	for _, mv := range moves {
+		next := game.Pos{X: pos.X + mv.X, Y: pos.Y + mv.Y}
+		if m.walkable(next) {
+			neighbors = append(neighbors, next)
+		}
+	}
+	return neighbors
+}
+
+func (m known) Cost(from, to game.Pos) int {
+	return 1
+}
+
+// frontier reports whether pos is next to a tile that hasn't been seen, and
+// somewhere the bot hasn't been yet.
+func (m known) frontier(pos game.Pos) bool {
+	if m.stood[levelPos{m.snap.Name, pos}] {
+		return false
+	}
+	for _, mv := range moves {
+		next := game.Pos{X: pos.X + mv.X, Y: pos.Y + mv.Y}
+		if next.Y >= 0 && next.Y < len(m.snap.Map) && next.X >= 0 && next.X < len(m.snap.Map[next.Y]) && !m.snap.Map[next.Y][next.X].Seen {
+			return true
+		}
+	}
+	return false
+}
+
+// sortPositions sorts positions top to bottom and left to right, so that the
+// bot doesn't choose differently depending on the order of a map.
+func sortPositions(positions []game.Pos) {
+	sort.Slice(positions, func(i, j int) bool {
+		if positions[i].Y != positions[j].Y {
+			return positions[i].Y < positions[j].Y
+		}
+		return positions[i].X < positions[j].X
+	})
+}
+
+func point(pos game.Pos) pathfind.Point {
+	return pathfind.Point{X: pos.X, Y: pos.Y}
+}
+
+func step(pos game.Pos, input game.InputType) game.Pos {
+	for _, mv := range moves {
+		if mv.input == input {
+			return game.Pos{X: pos.X + mv.X, Y: pos.Y + mv.Y}
+		}
+	}
+	return pos
+}
+
+// move is the input that steps from one tile to the one next to it.
+func move(from, to game.Pos) *game.Input {
+	for _, mv := range moves {
+		if from.X+mv.X == to.X && from.Y+mv.Y == to.Y {
+			return &game.Input{Type: mv.input}
+		}
+	}
+	return &game.Input{Type: game.Search}
+}
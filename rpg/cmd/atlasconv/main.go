@@ -0,0 +1,65 @@
+// Command atlasconv converts an old atlas-index.txt from the rpg into an
+// atlas.json descriptor.
+//
+//	atlasconv [-image tiles.png] in [out]
+//
+// out defaults to atlas.json beside in, and is overwritten.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/LucasK1/gameswithgo/rpg/atlas"
+)
+
+func main() {
+	image := flag.String("image", "tiles.png", "atlas image the old index refers to")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: atlasconv [-image tiles.png] in [out]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() < 1 || flag.NArg() > 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	in := flag.Arg(0)
+	out := filepath.Join(filepath.Dir(in), "atlas.json")
+	if flag.NArg() > 1 {
+		out = flag.Arg(1)
+	}
+
+	err := convert(in, out, *image)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func convert(in, out, image string) error {
+	infile, err := os.Open(in)
+	if err != nil {
+		return err
+	}
+	defer infile.Close()
+
+	a, err := atlas.ReadIndex(infile, image)
+	if err != nil {
+		return fmt.Errorf("%s: %v", in, err)
+	}
+
+	outfile, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	err = a.Write(outfile)
+	if err != nil {
+		outfile.Close()
+		return err
+	}
+	return outfile.Close()
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/LucasK1/gameswithgo/rpg/atlas"
+)
+
+func TestConvert(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "atlas-index.txt")
+	out := filepath.Join(dir, "atlas.json")
+	err := os.WriteFile(in, []byte("# 61, 0, 2\n@ 1, 2, 1\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = convert(in, out, "sheet.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := atlas.Read(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &atlas.Atlas{TileSize: 32, Images: map[string]string{"tiles": "sheet.png"}, Sprites: []atlas.Sprite{
+		{Name: "stone wall", Rune: "#", Image: "tiles", FrameTime: atlas.DefaultFrameTime, Variants: []atlas.Variant{
+			{Weight: 1, Frames: []atlas.Rect{{X: 61 * 32, Y: 0, W: 32, H: 32}}},
+			{Weight: 1, Frames: []atlas.Rect{{X: 62 * 32, Y: 0, W: 32, H: 32}}},
+		}},
+		{Name: "player", Rune: "@", Image: "tiles", FrameTime: atlas.DefaultFrameTime, Variants: []atlas.Variant{
+			{Weight: 1, Frames: []atlas.Rect{{X: 32, Y: 64, W: 32, H: 32}}},
+		}},
+	}}
+	if !reflect.DeepEqual(a, want) {
+		t.Errorf("converted to %+v, want %+v", a, want)
+	}
+}
+
+func TestConvertBadIndex(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "atlas-index.txt")
+	err := os.WriteFile(in, []byte("# 1, 2\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "atlas.json")
+	err = convert(in, out, "tiles.png")
+	if err == nil {
+		t.Errorf("converted an index line with two numbers")
+	}
+	_, err = os.Stat(out)
+	if !os.IsNotExist(err) {
+		t.Errorf("wrote %s from a bad index", out)
+	}
+	if convert(filepath.Join(dir, "missing.txt"), out, "tiles.png") == nil {
+		t.Errorf("converted a missing index")
+	}
+}
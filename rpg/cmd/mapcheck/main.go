@@ -0,0 +1,37 @@
+// Command mapcheck validates the rpg's .map files and world file without
+// starting the game. It prints one line per problem and exits with status 1
+// if there are any errors, or any warnings when -strict is given.
+//
+//	mapcheck [-strict] [dir]
+//
+// dir defaults to rpg/game/maps, relative to the repository root.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/LucasK1/gameswithgo/rpg/game"
+)
+
+func main() {
+	strict := flag.Bool("strict", false, "fail on warnings as well as errors")
+	flag.Parse()
+
+	dir := "rpg/game/maps"
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+
+	failed := false
+	for _, problem := range game.CheckWorld(dir) {
+		fmt.Println(problem)
+		if problem.Severity == game.Error || *strict {
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
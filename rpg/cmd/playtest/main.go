@@ -0,0 +1,30 @@
+// Command playtest lets a bot play the rpg over and over, each time from a
+// different seed, and prints how each game went and a summary of them all.
+//
+//	playtest [-runs n] [-seed s] [-turns t] [-v]
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/LucasK1/gameswithgo/rpg/bot"
+)
+
+func main() {
+	runs := flag.Int("runs", 20, "number of games to play")
+	seed := flag.Int64("seed", 1, "seed of the first game; the others follow on from it")
+	turns := flag.Int("turns", 2000, "turns a game may last")
+	verbose := flag.Bool("v", false, "print every game, not just the summary")
+	flag.Parse()
+
+	stats := make([]bot.Stats, 0, *runs)
+	for i := 0; i < *runs; i++ {
+		s := bot.Play(*seed+int64(i), *turns)
+		if *verbose {
+			fmt.Println(s)
+		}
+		stats = append(stats, s)
+	}
+	fmt.Println(bot.Summarize(stats))
+}
@@ -0,0 +1,97 @@
+// Command sfxgen synthesizes the rpg's combat and portal sound effects, for
+// which the Kenney pack has nothing, as mono 16-bit WAV files.
+//
+//	sfxgen [dir]
+//
+// dir defaults to rpg/ui2d/assets, relative to the repository root. The files
+// come out the same every time.
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+)
+
+const sampleRate = 22050
+
+// sound is a sample, between -1 and 1, for each time t in seconds.
+type sound struct {
+	name     string
+	duration float64
+	sample   func(t float64, noise float64) float64
+}
+
+var sounds = []sound{
+	// A swish of noise rising and falling.
+	{"attack", 0.22, func(t, noise float64) float64 {
+		return noise * math.Sin(math.Pi*t/0.22) * 0.5
+	}},
+	// A low thud with a crack of noise at the start.
+	{"hit", 0.25, func(t, noise float64) float64 {
+		thud := math.Sin(2*math.Pi*(90-120*t)*t) * math.Exp(-t*18)
+		crack := noise * math.Exp(-t*60)
+		return 0.7*thud + 0.4*crack
+	}},
+	// A groan falling away.
+	{"death", 0.7, func(t, noise float64) float64 {
+		f := 220 * math.Exp(-t*1.6)
+		tone := math.Sin(2*math.Pi*f*t) + 0.4*math.Sin(4*math.Pi*f*t)
+		return 0.4 * tone * (1 - t/0.7) * math.Min(1, t*40)
+	}},
+	// A shimmering chord sweeping up.
+	{"portal", 0.6, func(t, noise float64) float64 {
+		v := 0.0
+		for _, f := range []float64{330, 415, 494} {
+			f *= 1 + t
+			v += math.Sin(2 * math.Pi * f * t * (1 + 0.01*math.Sin(2*math.Pi*7*t)))
+		}
+		return 0.25 * v * math.Sin(math.Pi*t/0.6)
+	}},
+}
+
+func main() {
+	dir := "rpg/ui2d/assets"
+	if len(os.Args) > 1 {
+		dir = os.Args[1]
+	}
+	for _, s := range sounds {
+		err := writeWAV(filepath.Join(dir, s.name+".wav"), s)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+}
+
+func writeWAV(path string, s sound) error {
+	r := rand.New(rand.NewSource(1))
+	samples := make([]int16, int(s.duration*sampleRate))
+	for i := range samples {
+		v := s.sample(float64(i)/sampleRate, r.Float64()*2-1)
+		samples[i] = int16(math.Max(-1, math.Min(1, v)) * math.MaxInt16)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	size := uint32(len(samples) * 2)
+	header := []interface{}{
+		[4]byte{'R', 'I', 'F', 'F'}, 36 + size, [4]byte{'W', 'A', 'V', 'E'},
+		[4]byte{'f', 'm', 't', ' '}, uint32(16), uint16(1), uint16(1),
+		uint32(sampleRate), uint32(sampleRate * 2), uint16(2), uint16(16),
+		[4]byte{'d', 'a', 't', 'a'}, size,
+	}
+	for _, field := range header {
+		err = binary.Write(file, binary.LittleEndian, field)
+		if err != nil {
+			return err
+		}
+	}
+	return binary.Write(file, binary.LittleEndian, samples)
+}
@@ -0,0 +1,554 @@
+package game
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/LucasK1/gameswithgo/pathfind"
+)
+
+type Severity int
+
+const (
+	Warning Severity = iota
+	Error
+)
+
+// Problem is something wrong with the map or world files found by CheckWorld.
+// Line and Col are 1-based and zero when the problem isn't tied to a place in
+// the file.
+type Problem struct {
+	Severity Severity
+	File     string
+	Line     int
+	Col      int
+	Msg      string
+}
+
+func (p Problem) String() string {
+	where := p.File
+	if p.Line > 0 {
+		where += fmt.Sprintf(":%d", p.Line)
+		if p.Col > 0 {
+			where += fmt.Sprintf(":%d", p.Col)
+		}
+	}
+	kind := "warning"
+	if p.Severity == Error {
+		kind = "error"
+	}
+	return where + ": " + kind + ": " + p.Msg
+}
+
+type checker struct {
+	problems []Problem
+}
+
+func (c *checker) report(severity Severity, file string, pos *Pos, format string, args ...interface{}) {
+	p := Problem{Severity: severity, File: file, Msg: fmt.Sprintf(format, args...)}
+	if pos != nil {
+		p.Line = pos.Y + 1
+		p.Col = pos.X + 1
+	}
+	c.problems = append(c.problems, p)
+}
+
+// CheckWorld loads every .map file in dir and the world file with the same
+// parsers the game uses and reports everything that would make the game
+// panic or leave parts of the world unplayable.
+func CheckWorld(dir string) []Problem {
+	c := &checker{}
+
+	levelpaths, err := filepath.Glob(filepath.Join(dir, "*.map"))
+	if err != nil {
+		c.report(Error, dir, nil, "%v", err)
+		return c.problems
+	}
+
+	levels := make(map[string]*Level)
+	player := newPlayer()
+	ids := &characterIDs{}
+	for _, levelpath := range levelpaths {
+		file := filepath.Base(levelpath)
+		levelName := strings.TrimSuffix(file, ".map")
+
+		levelLines, err := readMapFile(levelpath)
+		if err != nil {
+			c.report(Error, file, nil, "%v", err)
+			continue
+		}
+		if len(levelLines) == 0 {
+			c.report(Error, file, nil, "map is empty")
+			continue
+		}
+
+		level, invalid := parseLevel(levelName, levelLines, player, ids)
+		for _, pos := range invalid {
+			pos := pos
+			c.report(Error, file, &pos, "unknown rune %q", []rune(levelLines[pos.Y][pos.X:])[0])
+		}
+
+		longestRow := len(level.Map[0])
+		for y, line := range levelLines {
+			if len(line) < longestRow && cutsOff(level, y, len(line)) {
+				c.report(Warning, file, &Pos{len(line), y}, "row is %d wide, the longest row is %d, leaving walkable ground beside it unwalled", len(line), longestRow)
+			}
+		}
+
+		levels[levelName] = level
+	}
+
+	start := c.checkPortals(levels, dir)
+
+	names := make([]string, 0, len(levels))
+	for name := range levels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		c.checkStairs(levels[name])
+	}
+
+	if start != nil {
+		reachedLevels := reachableLevels(start)
+		for _, name := range names {
+			level := levels[name]
+			if !reachedLevels[level] {
+				c.report(Warning, name+".map", nil, "level can't be reached from the start level %s", start.Name)
+				continue
+			}
+			c.checkReachable(level, level == start, levels)
+		}
+	}
+
+	c.checkStory(levels, dir)
+	c.checkEconomy(dir)
+
+	_, err = loadScripts(dir)
+	if err != nil {
+		c.report(Error, scriptDir, nil, "%v", err)
+	}
+
+	return c.problems
+}
+
+// checkPortals reads the world file, installs its level metadata and portals
+// in levels and returns the start level, or nil if the world file is unusable.
+func (c *checker) checkPortals(levels map[string]*Level, dir string) *Level {
+	world, file, err := readWorld(dir)
+	if err != nil {
+		c.report(Error, file, nil, "%v", err)
+		return nil
+	}
+
+	startLevel := levels[world.Start]
+	if startLevel == nil {
+		c.report(Error, file, nil, "start level %q has no .map file", world.Start)
+	} else if !startLevel.HasStart {
+		c.report(Error, world.Start+".map", nil, "start level has no @ for the player")
+	}
+
+	for i, info := range world.Levels {
+		level := levels[info.Name]
+		if level == nil {
+			c.report(Error, file, nil, "levels[%d]: level %q has no .map file", i, info.Name)
+			continue
+		}
+		for _, spawn := range info.Spawns {
+			if monsterTypes[spawn.Monster] == nil {
+				c.report(Error, file, nil, "levels[%d]: unknown monster %q in spawn table", i, spawn.Monster)
+			}
+			if spawn.Weight <= 0 {
+				c.report(Warning, file, nil, "levels[%d]: %s has spawn weight %d and will never spawn", i, spawn.Monster, spawn.Weight)
+			}
+		}
+		if (info.SpawnEvery > 0 || len(info.Spawners) > 0) && info.MaxMonsters <= 0 {
+			c.report(Warning, file, nil, "levels[%d]: maxMonsters is %d, so no monsters will spawn", i, info.MaxMonsters)
+		}
+		for j, spawner := range info.Spawners {
+			pos := Pos{X: spawner.X, Y: spawner.Y}
+			switch {
+			case !inRange(level, pos):
+				c.report(Error, file, nil, "levels[%d]: spawner %d at %d,%d is outside %s", i, j, spawner.X, spawner.Y, info.Name)
+			case level.Map[pos.Y][pos.X].Rune == StoneWall || level.Map[pos.Y][pos.X].Rune == Blank:
+				c.report(Error, file, nil, "levels[%d]: spawner %d at %d,%d is in a wall", i, j, spawner.X, spawner.Y)
+			}
+			if spawner.Monster != "" && monsterTypes[spawner.Monster] == nil {
+				c.report(Error, file, nil, "levels[%d]: unknown monster %q in spawner %d", i, spawner.Monster, j)
+			}
+			if spawner.Monster == "" && len(info.Spawns) == 0 {
+				c.report(Error, file, nil, "levels[%d]: spawner %d has no monster and the level has no spawn table", i, j)
+			}
+			if spawner.Every <= 0 || spawner.Max <= 0 {
+				c.report(Warning, file, nil, "levels[%d]: spawner %d spawns every %d turns up to %d and will never spawn", i, j, spawner.Every, spawner.Max)
+			}
+		}
+		for j, light := range info.Lights {
+			if !inRange(level, Pos{X: light.X, Y: light.Y}) {
+				c.report(Error, file, nil, "levels[%d]: light %d at %d,%d is outside %s", i, j, light.X, light.Y, info.Name)
+			} else if light.Radius <= 0 {
+				c.report(Warning, file, nil, "levels[%d]: light %d at %d,%d has radius %d and lights nothing", i, j, light.X, light.Y, light.Radius)
+			}
+		}
+		level.Title = info.Title
+		level.Depth = info.Depth
+	}
+
+	for i, portal := range world.Portals {
+		// Portals from world.txt know their line, those from world.json
+		// only their index.
+		var at *Pos
+		where := fmt.Sprintf("portals[%d]: ", i)
+		if portal.line > 0 {
+			at = &Pos{0, portal.line - 1}
+			where = ""
+		}
+		from := levels[portal.From.Level]
+		to := levels[portal.To.Level]
+		if from == nil {
+			c.report(Error, file, at, "%sportal from unknown level %q", where, portal.From.Level)
+		}
+		if to == nil {
+			c.report(Error, file, at, "%sportal to unknown level %q", where, portal.To.Level)
+		}
+		if from == nil || to == nil {
+			continue
+		}
+
+		pos := portal.From.Pos()
+		dest := portal.To.Pos()
+		ok := true
+		if !inRange(from, pos) {
+			c.report(Error, file, at, "%sportal at %d,%d is outside %s", where, pos.X, pos.Y, from.Name)
+			ok = false
+		} else if !walkableTerrain(from, pos) {
+			c.report(Error, file, at, "%sportal at %d,%d is in a wall on %s", where, pos.X, pos.Y, from.Name)
+			ok = false
+		}
+		if !inRange(to, dest) {
+			c.report(Error, file, at, "%sportal destination %d,%d is outside %s", where, dest.X, dest.Y, to.Name)
+			ok = false
+		} else if !walkableTerrain(to, dest) {
+			c.report(Error, file, at, "%sportal destination %d,%d is in a wall on %s", where, dest.X, dest.Y, to.Name)
+			ok = false
+		}
+		if ok {
+			from.Portals[pos] = &LevelPos{Level: to, Pos: dest, Requires: portal.Requires}
+		}
+	}
+	return startLevel
+}
+
+func (c *checker) checkStairs(level *Level) {
+	file := level.Name + ".map"
+	for y, row := range level.Map {
+		for x, tile := range row {
+			if tile.OverlayRune != UpStair && tile.OverlayRune != DownStair {
+				continue
+			}
+			pos := Pos{x, y}
+			dest := level.Portals[pos]
+			if dest == nil {
+				c.report(Error, file, &pos, "stairs have no portal")
+				continue
+			}
+			want := UpStair
+			if tile.OverlayRune == UpStair {
+				want = DownStair
+			}
+			if dest.Level.Map[dest.Y][dest.X].OverlayRune != want {
+				c.report(Warning, file, &pos, "stairs lead to %s %d,%d which has no %q", dest.Level.Name, dest.X, dest.Y, want)
+			}
+			back := dest.Level.Portals[dest.Pos]
+			if back == nil || back.Level != level || back.Pos != pos {
+				c.report(Warning, file, &pos, "stairs to %s %d,%d have no way back", dest.Level.Name, dest.X, dest.Y)
+			}
+		}
+	}
+}
+
+// checkStory reports NPCs that can't be put where story.json says and
+// dialogues and quests that refer to things that don't exist.
+func (c *checker) checkStory(levels map[string]*Level, dir string) {
+	story, err := readStory(dir)
+	if err != nil {
+		c.report(Error, storyFile, nil, "%v", err)
+		return
+	}
+
+	quests := make(map[string]bool)
+	for i, q := range story.Quests {
+		if q.Name == "" || quests[q.Name] {
+			c.report(Error, storyFile, nil, "quests[%d]: quest name %q is empty or used twice", i, q.Name)
+		}
+		quests[q.Name] = true
+		if len(q.Objectives) == 0 {
+			c.report(Warning, storyFile, nil, "quests[%d]: %s has no objectives and is complete as soon as it starts", i, q.Name)
+		}
+		for _, o := range q.Objectives {
+			switch {
+			case o.Kill != "" && o.Visit != "":
+				c.report(Error, storyFile, nil, "quests[%d]: objective both kills %s and visits %s", i, o.Kill, o.Visit)
+			case o.Kill != "" && monsterTypes[o.Kill] == nil:
+				c.report(Error, storyFile, nil, "quests[%d]: unknown monster %q", i, o.Kill)
+			case o.Visit != "" && levels[o.Visit] == nil:
+				c.report(Error, storyFile, nil, "quests[%d]: unknown level %q", i, o.Visit)
+			case o.Kill == "" && o.Visit == "":
+				c.report(Error, storyFile, nil, "quests[%d]: objective neither kills nor visits anything", i)
+			}
+		}
+	}
+
+	checkCondition := func(where string, cond *Condition) {
+		if cond != nil && cond.Quest != "" && !quests[cond.Quest] {
+			c.report(Error, storyFile, nil, "%s: unknown quest %q", where, cond.Quest)
+		}
+	}
+	names := make([]string, 0, len(story.Dialogues))
+	for name := range story.Dialogues {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		dialogue := story.Dialogues[name]
+		if len(dialogue.Greetings) == 0 {
+			c.report(Warning, storyFile, nil, "dialogue %s has no greetings and never starts", name)
+		}
+		for i, greeting := range dialogue.Greetings {
+			where := fmt.Sprintf("dialogue %s greetings[%d]", name, i)
+			checkCondition(where, greeting.If)
+			if dialogue.Nodes[greeting.Node] == nil {
+				c.report(Error, storyFile, nil, "%s: unknown node %q", where, greeting.Node)
+			}
+		}
+		nodeNames := make([]string, 0, len(dialogue.Nodes))
+		for nodeName := range dialogue.Nodes {
+			nodeNames = append(nodeNames, nodeName)
+		}
+		sort.Strings(nodeNames)
+		for _, nodeName := range nodeNames {
+			for i, choice := range dialogue.Nodes[nodeName].Choices {
+				where := fmt.Sprintf("dialogue %s node %s choices[%d]", name, nodeName, i)
+				checkCondition(where, choice.If)
+				if choice.Next != "" && dialogue.Nodes[choice.Next] == nil {
+					c.report(Error, storyFile, nil, "%s: unknown node %q", where, choice.Next)
+				}
+				if choice.Do == nil {
+					continue
+				}
+				for _, q := range []string{choice.Do.Start, choice.Do.Finish} {
+					if q != "" && !quests[q] {
+						c.report(Error, storyFile, nil, "%s: unknown quest %q", where, q)
+					}
+				}
+			}
+		}
+	}
+
+	for i, info := range story.NPCs {
+		where := fmt.Sprintf("npcs[%d]", i)
+		if info.Dialogue != "" && story.Dialogues[info.Dialogue] == nil {
+			c.report(Warning, storyFile, nil, "%s: %s has unknown dialogue %q", where, info.Name, info.Dialogue)
+		}
+		if info.Dialogue == "" && info.Shop == "" {
+			c.report(Warning, storyFile, nil, "%s: %s has no dialogue or shop and nothing to say", where, info.Name)
+		}
+		level := levels[info.Level]
+		pos := Pos{X: info.X, Y: info.Y}
+		switch {
+		case level == nil:
+			c.report(Error, storyFile, nil, "%s: %s is on unknown level %q", where, info.Name, info.Level)
+		case !walkableTerrain(level, pos):
+			c.report(Error, storyFile, nil, "%s: %s at %d,%d is in a wall on %s", where, info.Name, pos.X, pos.Y, level.Name)
+		case level.Portals[pos] != nil:
+			c.report(Error, storyFile, nil, "%s: %s at %d,%d stands on a portal", where, info.Name, pos.X, pos.Y)
+		case level.Monsters[pos] != nil:
+			c.report(Error, storyFile, nil, "%s: %s at %d,%d stands on a monster", where, info.Name, pos.X, pos.Y)
+		}
+	}
+}
+
+// checkEconomy reports prices, drops and shops in economy.json that refer to
+// things that don't exist, and shopkeepers in story.json without a shop.
+func (c *checker) checkEconomy(dir string) {
+	economy, err := readEconomy(dir)
+	if err != nil {
+		c.report(Error, economyFile, nil, "%v", err)
+		return
+	}
+
+	items := make(map[string]bool)
+	for i, info := range economy.Items {
+		if info.Name == "" || items[info.Name] {
+			c.report(Error, economyFile, nil, "items[%d]: item name %q is empty or used twice", i, info.Name)
+		}
+		items[info.Name] = true
+		if info.Price <= 0 {
+			c.report(Warning, economyFile, nil, "items[%d]: %s costs %d", i, info.Name, info.Price)
+		}
+	}
+	for i, drop := range economy.Drops {
+		if monsterTypes[drop.Monster] == nil {
+			c.report(Error, economyFile, nil, "drops[%d]: unknown monster %q", i, drop.Monster)
+		}
+		if drop.Min < 0 || drop.Max < drop.Min {
+			c.report(Error, economyFile, nil, "drops[%d]: %s drops between %d and %d gold", i, drop.Monster, drop.Min, drop.Max)
+		}
+		for _, item := range drop.Items {
+			if item.Chance <= 0 || item.Chance > 100 {
+				c.report(Warning, economyFile, nil, "drops[%d]: %s drops %s %d%% of the time", i, drop.Monster, item.Item, item.Chance)
+			}
+		}
+	}
+	for i, shop := range economy.Shops {
+		if shop.Buys < 0 || shop.Buys > 100 {
+			c.report(Warning, economyFile, nil, "shops[%d]: %s buys at %d%% of the price", i, shop.Name, shop.Buys)
+		}
+		for _, s := range shop.Stock {
+			if !items[s.Item] {
+				c.report(Error, economyFile, nil, "shops[%d]: %s sells %q, which has no price", i, shop.Name, s.Item)
+			}
+			if s.Count > s.Max {
+				c.report(Warning, economyFile, nil, "shops[%d]: %s starts with %d %s, more than its max %d", i, shop.Name, s.Count, s.Item, s.Max)
+			}
+		}
+	}
+
+	story, err := readStory(dir)
+	if err != nil {
+		return
+	}
+	for i, info := range story.NPCs {
+		if info.Shop != "" && economy.shop(info.Shop) == nil {
+			c.report(Error, storyFile, nil, "npcs[%d]: %s runs unknown shop %q", i, info.Name, info.Shop)
+		}
+	}
+}
+
+// checkReachable reports walkable areas of level the player can never get to,
+// and the monsters shut inside them.
+func (c *checker) checkReachable(level *Level, isStart bool, levels map[string]*Level) {
+	file := level.Name + ".map"
+
+	entries := make([]Pos, 0)
+	if isStart && level.HasStart {
+		entries = append(entries, level.Start)
+	}
+	for _, other := range levels {
+		for _, dest := range other.Portals {
+			if dest.Level == level {
+				entries = append(entries, dest.Pos)
+			}
+		}
+	}
+	reached := level.reachableFrom(entries)
+
+	monsters := make([]Pos, 0, len(level.Monsters))
+	for pos := range level.Monsters {
+		monsters = append(monsters, pos)
+	}
+	sort.Slice(monsters, func(i, j int) bool {
+		if monsters[i].Y != monsters[j].Y {
+			return monsters[i].Y < monsters[j].Y
+		}
+		return monsters[i].X < monsters[j].X
+	})
+
+	for y, row := range level.Map {
+		for x := range row {
+			pos := Pos{x, y}
+			if !walkableTerrain(level, pos) || reached[pos] {
+				continue
+			}
+			area := level.reachableFrom([]Pos{pos})
+			for p := range area {
+				reached[p] = true
+			}
+			c.report(Error, file, &pos, "area of %d tiles can't be reached", len(area))
+			for _, p := range monsters {
+				if area[p] {
+					p := p
+					c.report(Error, file, &p, "%s is sealed in an unreachable area", level.Monsters[p].Name)
+				}
+			}
+		}
+	}
+}
+
+// walkableTerrain is canWalk for someone who can open doors and doesn't care
+// about monsters.
+func walkableTerrain(level *Level, pos Pos) bool {
+	if !inRange(level, pos) {
+		return false
+	}
+	switch level.Map[pos.Y][pos.X].Rune {
+	case StoneWall, Blank:
+		return false
+	}
+	return true
+}
+
+// reachableFrom is every tile walkable terrain connects to one of starts.
+func (level *Level) reachableFrom(starts []Pos) map[Pos]bool {
+	reached := make(map[Pos]bool)
+	for _, start := range starts {
+		if !walkableTerrain(level, start) || reached[start] {
+			continue
+		}
+		for pos := range pathfind.Distances[Pos](terrainGraph{level}, start) {
+			reached[pos] = true
+		}
+	}
+	return reached
+}
+
+// terrainGraph is the level as a graph of walkableTerrain.
+type terrainGraph struct {
+	level *Level
+}
+
+func (g terrainGraph) Neighbors(pos Pos) []Pos {
+	neighbors := make([]Pos, 0, 4)
+	for _, next := range []Pos{{pos.X + 1, pos.Y}, {pos.X - 1, pos.Y}, {pos.X, pos.Y - 1}, {pos.X, pos.Y + 1}} {
+		if walkableTerrain(g.level, next) {
+			neighbors = append(neighbors, next)
+		}
+	}
+	return neighbors
+}
+
+func (g terrainGraph) Cost(from, to Pos) int {
+	return 1
+}
+
+// cutsOff reports whether row y, ending at width, leaves walkable ground
+// beside the blank tiles it is padded with, as a missing wall would.
+func cutsOff(level *Level, y, width int) bool {
+	for x := width; x < len(level.Map[y]); x++ {
+		for _, next := range []Pos{{x - 1, y}, {x, y - 1}, {x, y + 1}} {
+			if walkableTerrain(level, next) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func reachableLevels(start *Level) map[*Level]bool {
+	visited := map[*Level]bool{start: true}
+	frontier := []*Level{start}
+	for len(frontier) > 0 {
+		current := frontier[0]
+		frontier = frontier[1:]
+		for _, dest := range current.Portals {
+			if !visited[dest.Level] {
+				visited[dest.Level] = true
+				frontier = append(frontier, dest.Level)
+			}
+		}
+	}
+	return visited
+}
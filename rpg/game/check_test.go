@@ -0,0 +1,112 @@
+package game
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// checkMaps writes maps and world to a temporary directory, as
+// NewGameFromMaps does but without refusing broken maps, and checks it.
+func checkMaps(t *testing.T, world *World, maps map[string]string) []Problem {
+	t.Helper()
+	dir := t.TempDir()
+	for name, m := range maps {
+		err := os.WriteFile(filepath.Join(dir, name+".map"), []byte(strings.Join(mapLines(m), "\n")+"\n"), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	file, err := os.Create(filepath.Join(dir, worldFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = world.Write(file)
+	file.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return CheckWorld(dir)
+}
+
+// wantProblems fails unless problems are exactly want, as Problem.String
+// writes them.
+func wantProblems(t *testing.T, problems []Problem, want ...string) {
+	t.Helper()
+	got := make([]string, len(problems))
+	for i, p := range problems {
+		got[i] = p.String()
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("problems:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestCheckShippedWorld(t *testing.T) {
+	wantProblems(t, CheckWorld("maps"))
+}
+
+func TestCheckShortRows(t *testing.T) {
+	world := &World{Start: "a"}
+
+	// Rows trimmed after their last wall, as WriteMap trims them, are fine.
+	wantProblems(t, checkMaps(t, world, map[string]string{"a": `
+		#####
+		#@..###
+		#######
+	`}))
+
+	wantProblems(t, checkMaps(t, world, map[string]string{"a": `
+		#######
+		#@....
+		#######
+	`}), "a.map:2:7: warning: row is 6 wide, the longest row is 7, leaving walkable ground beside it unwalled")
+}
+
+func TestCheckUnreachable(t *testing.T) {
+	wantProblems(t, checkMaps(t, &World{Start: "a"}, map[string]string{"a": `
+		#########
+		#@.#.SR.#
+		####.R###
+		#########
+	`}),
+		"a.map:2:5: error: area of 6 tiles can't be reached",
+		"a.map:2:6: error: Spider is sealed in an unreachable area",
+		"a.map:2:7: error: Rat is sealed in an unreachable area",
+		"a.map:3:6: error: Rat is sealed in an unreachable area",
+	)
+}
+
+func TestCheckPortals(t *testing.T) {
+	world := &World{Start: "a", Portals: []PortalInfo{
+		{From: PortalEnd{Level: "a", X: 3, Y: 1}, To: PortalEnd{Level: "b", X: 1, Y: 1}},
+		{From: PortalEnd{Level: "a", X: 0, Y: 0}, To: PortalEnd{Level: "b", X: 2, Y: 1}},
+		{From: PortalEnd{Level: "a", X: 2, Y: 1}, To: PortalEnd{Level: "c", X: 1, Y: 1}},
+	}}
+	wantProblems(t, checkMaps(t, world, map[string]string{
+		"a": `
+			#####
+			#@.d#
+			#####
+		`,
+		"b": `
+			####
+			#..#
+			####
+		`,
+	}),
+		"world.json: error: portals[1]: portal at 0,0 is in a wall on a",
+		"world.json: error: portals[2]: portal to unknown level \"c\"",
+		"a.map:2:4: warning: stairs lead to b 1,1 which has no 'u'",
+		"a.map:2:4: warning: stairs to b 1,1 have no way back",
+	)
+}
+
+func TestCheckUnknownRune(t *testing.T) {
+	wantProblems(t, checkMaps(t, &World{Start: "a"}, map[string]string{"a": `
+		#####
+		#@.x#
+		#####
+	`}), "a.map:2:4: error: unknown rune 'x'")
+}
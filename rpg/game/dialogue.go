@@ -0,0 +1,249 @@
+package game
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+const storyFile = "story.json"
+
+// Story is the contents of story.json: the friendly characters on the
+// levels, what they say and the quests they hand out.
+//
+// A dialogue is a tree of nodes, each some text and the choices the player
+// can answer with, like the story nodes of textAdventure. Talking to an NPC
+// starts at the node of the first of its dialogue's greetings whose condition
+// holds. Choices whose condition doesn't hold aren't offered, and picking a
+// choice carries out its action and moves to its next node, or ends the
+// conversation when it has none.
+type Story struct {
+	NPCs      []NPCInfo            `json:"npcs,omitempty"`
+	Dialogues map[string]*Dialogue `json:"dialogues,omitempty"`
+	Quests    []*Quest             `json:"quests,omitempty"`
+}
+
+// NPCInfo places an NPC, talking with the dialogue called Dialogue. NPCs with
+// a Shop and no dialogue trade as soon as they are talked to.
+type NPCInfo struct {
+	Name     string `json:"name"`
+	Rune     string `json:"rune"`
+	Level    string `json:"level"`
+	X        int    `json:"x"`
+	Y        int    `json:"y"`
+	Dialogue string `json:"dialogue,omitempty"`
+	Shop     string `json:"shop,omitempty"`
+}
+
+type Dialogue struct {
+	Greetings []Greeting               `json:"greetings"`
+	Nodes     map[string]*DialogueNode `json:"nodes"`
+}
+
+type Greeting struct {
+	If   *Condition `json:"if,omitempty"`
+	Node string     `json:"node"`
+}
+
+type DialogueNode struct {
+	Text    string   `json:"text"`
+	Choices []Choice `json:"choices,omitempty"`
+}
+
+type Choice struct {
+	Text string     `json:"text"`
+	Next string     `json:"next,omitempty"`
+	If   *Condition `json:"if,omitempty"`
+	Do   *Action    `json:"do,omitempty"`
+}
+
+// Condition holds when the quest called Quest has Status, which is unstarted
+// when left out, and the player carries Item.
+type Condition struct {
+	Quest  string      `json:"quest,omitempty"`
+	Status QuestStatus `json:"status,omitempty"`
+	Item   string      `json:"item,omitempty"`
+}
+
+// Action starts a quest, finishes one that is complete, gives the player an
+// item or, with Trade, ends the conversation and opens the speaker's shop.
+type Action struct {
+	Start  string `json:"start,omitempty"`
+	Finish string `json:"finish,omitempty"`
+	Give   string `json:"give,omitempty"`
+	Trade  bool   `json:"trade,omitempty"`
+}
+
+// NPC is a friendly character the player talks to by walking into it.
+type NPC struct {
+	Character
+	Dialogue string
+	Shop     string
+}
+
+// readStory reads story.json in dir. A world without one has no NPCs or
+// quests.
+func readStory(dir string) (*Story, error) {
+	file, err := os.Open(filepath.Join(dir, storyFile))
+	if os.IsNotExist(err) {
+		return &Story{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	story := &Story{}
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(story)
+	if err != nil {
+		return nil, err
+	}
+	return story, nil
+}
+
+// placeNPCs puts the story's NPCs on their levels, skipping those on levels
+// that don't exist.
+func (story *Story) placeNPCs(levels map[string]*Level) {
+	for _, info := range story.NPCs {
+		level := levels[info.Level]
+		if level == nil {
+			continue
+		}
+		pos := Pos{X: info.X, Y: info.Y}
+		npc := &NPC{Dialogue: info.Dialogue, Shop: info.Shop}
+		npc.Pos = pos
+		npc.Name = info.Name
+		npc.Rune = []rune(info.Rune + "N")[0]
+		npc.HP = 1
+		level.NPCs[pos] = npc
+	}
+}
+
+// conversation is the local player talking to an NPC. While it lasts the
+// player's inputs answer it instead of playing turns.
+type conversation struct {
+	npc      *NPC
+	dialogue *Dialogue
+	node     *DialogueNode
+	choices  []Choice
+}
+
+// DialogueView is what the UI shows of a conversation: who is speaking, what
+// they say and the choices the player has. With no choices the player can
+// only leave.
+type DialogueView struct {
+	Speaker string
+	Text    string
+	Choices []string
+}
+
+func (gameStruct *Game) holds(cond *Condition) bool {
+	if cond == nil {
+		return true
+	}
+	if cond.Quest != "" && gameStruct.Quests.status(cond.Quest) != cond.Status {
+		return false
+	}
+	if cond.Item != "" && !gameStruct.CurrentLevel.Player.hasItem(cond.Item) {
+		return false
+	}
+	return true
+}
+
+func (gameStruct *Game) talkTo(npc *NPC) {
+	level := gameStruct.CurrentLevel
+	dialogue := gameStruct.Story.Dialogues[npc.Dialogue]
+	if dialogue != nil {
+		for _, greeting := range dialogue.Greetings {
+			if gameStruct.holds(greeting.If) {
+				gameStruct.talk = &conversation{npc: npc, dialogue: dialogue}
+				gameStruct.showNode(greeting.Node)
+				return
+			}
+		}
+	}
+	if npc.Shop != "" {
+		gameStruct.openShop(npc.Shop)
+		return
+	}
+	level.AddEvent(npc.Name + " has nothing to say")
+}
+
+// showNode moves the conversation to the named node, ending it if there is no
+// such node.
+func (gameStruct *Game) showNode(name string) {
+	talk := gameStruct.talk
+	node := talk.dialogue.Nodes[name]
+	if node == nil {
+		gameStruct.talk = nil
+		return
+	}
+	talk.node = node
+	talk.choices = talk.choices[:0]
+	for _, choice := range node.Choices {
+		if gameStruct.holds(choice.If) {
+			talk.choices = append(talk.choices, choice)
+		}
+	}
+}
+
+// answer handles the local player's input during a conversation. Choose
+// picks one of the choices on offer, and any other choice leaves.
+func (gameStruct *Game) answer(input *Input) {
+	switch input.Type {
+	case Choose:
+	case CloseWindow:
+		gameStruct.handleInput(input)
+		return
+	default:
+		return
+	}
+	talk := gameStruct.talk
+	if input.Choice < 0 || input.Choice >= len(talk.choices) {
+		gameStruct.talk = nil
+		return
+	}
+	choice := talk.choices[input.Choice]
+	gameStruct.act(choice.Do)
+	if choice.Do != nil && choice.Do.Trade {
+		gameStruct.talk = nil
+		gameStruct.openShop(talk.npc.Shop)
+		return
+	}
+	if choice.Next == "" {
+		gameStruct.talk = nil
+		return
+	}
+	gameStruct.showNode(choice.Next)
+}
+
+func (gameStruct *Game) act(action *Action) {
+	if action == nil {
+		return
+	}
+	level := gameStruct.CurrentLevel
+	if action.Start != "" {
+		gameStruct.Quests.start(action.Start, level)
+	}
+	if action.Finish != "" {
+		gameStruct.Quests.finish(action.Finish, &level.Player, level)
+	}
+	if action.Give != "" {
+		level.Player.give(action.Give)
+		level.AddEvent("Received " + action.Give)
+	}
+}
+
+func (gameStruct *Game) dialogueView() *DialogueView {
+	talk := gameStruct.talk
+	if talk == nil {
+		return nil
+	}
+	view := &DialogueView{Speaker: talk.npc.Name, Text: talk.node.Text}
+	for _, choice := range talk.choices {
+		view.Choices = append(view.Choices, choice.Text)
+	}
+	return view
+}
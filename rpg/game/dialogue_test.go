@@ -0,0 +1,126 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+)
+
+// hermitStory is a hermit on level a who sends the player to kill a rat and
+// visit level b, and only talks about the key to a player carrying it.
+var hermitStory = &Story{
+	NPCs: []NPCInfo{{Name: "Hermit", Rune: "N", Level: "a", X: 1, Y: 1, Dialogue: "hermit"}},
+	Dialogues: map[string]*Dialogue{"hermit": {
+		Greetings: []Greeting{
+			{If: &Condition{Quest: "rat", Status: QuestComplete}, Node: "thanks"},
+			{If: &Condition{Quest: "rat", Status: QuestActive}, Node: "waiting"},
+			{Node: "hello"},
+		},
+		Nodes: map[string]*DialogueNode{
+			"hello": {Text: "A rat!", Choices: []Choice{
+				{Text: "I'll kill it.", Do: &Action{Start: "rat"}},
+				{Text: "I have the key.", If: &Condition{Item: "Key"}},
+				{Text: "Goodbye."},
+			}},
+			"waiting": {Text: "Still there?"},
+			"thanks":  {Text: "Thank you.", Choices: []Choice{{Text: "My reward?", Do: &Action{Finish: "rat"}}}},
+		},
+	}},
+	Quests: []*Quest{{
+		Name:       "rat",
+		Title:      "The rat",
+		Objectives: []Objective{{Kill: "Rat"}, {Visit: "b"}},
+		Reward:     Reward{Strength: 5, Item: "Amulet"},
+	}},
+}
+
+func newHermitGame(t *testing.T) *testGame {
+	g := newTestGame(t, "a", map[string]string{
+		"a": `
+			#######
+			#.@R..#
+			#######
+		`,
+		"b": `
+			####
+			#..#
+			####
+		`,
+	})
+	g.content(storyFile, hermitStory)
+	g.link("a", Pos{5, 1}, "b", Pos{1, 1})
+	g.link("b", Pos{2, 1}, "a", Pos{4, 1})
+	return g
+}
+
+// wantDialogue checks what the hermit is saying and the choices offered.
+func (g *testGame) wantDialogue(text string, choices ...string) {
+	g.t.Helper()
+	view := g.dialogueView()
+	if view == nil {
+		g.t.Fatalf("not talking, want %q", text)
+	}
+	if view.Text != text || !reflect.DeepEqual(view.Choices, choices) {
+		g.t.Errorf("dialogue %q %q, want %q %q", view.Text, view.Choices, text, choices)
+	}
+}
+
+func TestDialogueConditions(t *testing.T) {
+	g := newHermitGame(t)
+
+	g.play(Left)
+	g.wantDialogue("A rat!", "I'll kill it.", "Goodbye.")
+	g.Step(&Input{Type: Choose, Choice: 1})
+	if g.talk != nil {
+		t.Fatalf("still talking after goodbye")
+	}
+
+	g.player().give("Key")
+	g.play(Left)
+	g.wantDialogue("A rat!", "I'll kill it.", "I have the key.", "Goodbye.")
+	g.Step(&Input{Type: Choose, Choice: 0})
+	g.wantEvent("New quest: The rat")
+
+	g.play(Left)
+	g.wantDialogue("Still there?")
+	g.Step(&Input{Type: Choose, Choice: -1})
+	if g.talk != nil {
+		t.Errorf("still talking after leaving")
+	}
+}
+
+func TestQuest(t *testing.T) {
+	g := newHermitGame(t)
+	g.play(Left)
+	g.Step(&Input{Type: Choose, Choice: 0})
+
+	for i := 0; i < 20 && len(g.CurrentLevel.Monsters) > 0; i++ {
+		g.play(Right)
+	}
+	state := g.Quests.States["rat"]
+	if !reflect.DeepEqual(state.Progress, []int{1, 0}) || state.Status != QuestActive {
+		t.Fatalf("quest %+v after killing the rat, want the kill counted", state)
+	}
+
+	g.play(Right, Right, Right)
+	g.wantPlayerAt("b", Pos{1, 1})
+	g.wantEvent("Quest complete: The rat")
+	if state.Status != QuestComplete {
+		t.Fatalf("quest %v after visiting b, want complete", state.Status)
+	}
+
+	g.play(Right, Left, Left)
+	g.wantPlayerAt("a", Pos{2, 1})
+	g.play(Left)
+	g.wantDialogue("Thank you.", "My reward?")
+	g.Step(&Input{Type: Choose, Choice: 0})
+	g.wantEvent("Quest done: The rat")
+	if state.Status != QuestDone || g.player().Strength != 25 || !g.player().hasItem("Amulet") {
+		t.Errorf("quest %v, strength %d, items %v, want done with the reward", state.Status, g.player().Strength, g.player().Items)
+	}
+
+	// Finishing again gives nothing more.
+	g.Quests.finish("rat", g.player(), g.CurrentLevel)
+	if g.player().Strength != 25 {
+		t.Errorf("rewarded twice, strength %d", g.player().Strength)
+	}
+}
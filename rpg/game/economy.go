@@ -0,0 +1,296 @@
+package game
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+const economyFile = "economy.json"
+
+// Economy is the contents of economy.json: what items cost, what each kind
+// of monster drops and what the shops sell.
+type Economy struct {
+	Items []ItemInfo `json:"items,omitempty"`
+	Drops []Drop     `json:"drops,omitempty"`
+	Shops []*Shop    `json:"shops,omitempty"`
+}
+
+// ItemInfo prices an item. Items that give HP or Strength are used up as
+// soon as they are bought.
+type ItemInfo struct {
+	Name     string `json:"name"`
+	Price    int    `json:"price"`
+	HP       int    `json:"hp,omitempty"`
+	Strength int    `json:"strength,omitempty"`
+}
+
+func (info *ItemInfo) consumable() bool {
+	return info.HP != 0 || info.Strength != 0
+}
+
+// Drop is what Monster leaves when it is killed: gold, between Min and Max,
+// and each of Items with its own chance.
+type Drop struct {
+	Monster string     `json:"monster"`
+	Min     int        `json:"min"`
+	Max     int        `json:"max"`
+	Items   []ItemDrop `json:"items,omitempty"`
+}
+
+// ItemDrop is an item dropped Chance percent of the time.
+type ItemDrop struct {
+	Item   string `json:"item"`
+	Chance int    `json:"chance"`
+}
+
+// Shop is run by the NPCs whose shop is Name. It buys items back at Buys
+// percent of their price.
+type Shop struct {
+	Name  string  `json:"name"`
+	Title string  `json:"title"`
+	Buys  int     `json:"buys"`
+	Stock []Stock `json:"stock"`
+}
+
+// Stock is how many of an item a shop has. One more comes in every Restock
+// turns until there are Max.
+type Stock struct {
+	Item    string `json:"item"`
+	Count   int    `json:"count"`
+	Max     int    `json:"max"`
+	Restock int    `json:"restock,omitempty"`
+}
+
+// readEconomy reads economy.json in dir. A world without one has nothing for
+// sale and monsters that drop nothing.
+func readEconomy(dir string) (*Economy, error) {
+	file, err := os.Open(filepath.Join(dir, economyFile))
+	if os.IsNotExist(err) {
+		return &Economy{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	economy := &Economy{}
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(economy)
+	if err != nil {
+		return nil, err
+	}
+	return economy, nil
+}
+
+func (economy *Economy) item(name string) *ItemInfo {
+	for i := range economy.Items {
+		if economy.Items[i].Name == name {
+			return &economy.Items[i]
+		}
+	}
+	return nil
+}
+
+func (economy *Economy) shop(name string) *Shop {
+	for _, shop := range economy.Shops {
+		if shop.Name == name {
+			return shop
+		}
+	}
+	return nil
+}
+
+// restock brings in new stock on the given turn.
+func (economy *Economy) restock(turn int) {
+	for _, shop := range economy.Shops {
+		for i := range shop.Stock {
+			s := &shop.Stock[i]
+			if s.Restock > 0 && turn%s.Restock == 0 && s.Count < s.Max {
+				s.Count++
+			}
+		}
+	}
+}
+
+// drop leaves the gold and items the monster drops where it was killed.
+func (gameStruct *Game) drop(monster *Monster, level *Level) {
+	for _, drop := range gameStruct.Economy.Drops {
+		if drop.Monster != monster.Name {
+			continue
+		}
+		gold := drop.Min
+		if drop.Max > drop.Min {
+			gold += gameStruct.rand.Intn(drop.Max - drop.Min + 1)
+		}
+		items := make([]string, 0)
+		for _, item := range drop.Items {
+			if gameStruct.rand.Intn(100) < item.Chance {
+				items = append(items, item.Item)
+			}
+		}
+		level.dropLoot(monster.Pos, gold, items)
+		return
+	}
+}
+
+// receive gives the player an item, using it up straight away if it is a
+// consumable.
+func (gameStruct *Game) receive(p *Player, name string) {
+	info := gameStruct.Economy.item(name)
+	if info != nil && info.consumable() {
+		p.HP += info.HP
+		p.Strength += info.Strength
+	} else {
+		p.give(name)
+	}
+}
+
+// shopping is the local player trading with a shop. Like a conversation it
+// takes the player's inputs until they leave.
+type shopping struct {
+	shop *Shop
+}
+
+// Offer is an item for sale, or one the player can sell, with how many there
+// are.
+type Offer struct {
+	Item  string
+	Price int
+	Count int
+}
+
+// ShopView is what the UI shows of the shop the local player is in.
+type ShopView struct {
+	Title string
+	Buy   []Offer
+	Sell  []Offer
+}
+
+func (gameStruct *Game) openShop(name string) {
+	shop := gameStruct.Economy.shop(name)
+	if shop == nil {
+		return
+	}
+	gameStruct.shopping = &shopping{shop: shop}
+}
+
+func (gameStruct *Game) shopView() *ShopView {
+	if gameStruct.shopping == nil {
+		return nil
+	}
+	shop := gameStruct.shopping.shop
+	view := &ShopView{Title: shop.Title, Buy: make([]Offer, 0), Sell: gameStruct.sellOffers()}
+	for _, s := range shop.Stock {
+		info := gameStruct.Economy.item(s.Item)
+		if info != nil {
+			view.Buy = append(view.Buy, Offer{Item: s.Item, Price: info.Price, Count: s.Count})
+		}
+	}
+	return view
+}
+
+// sellOffers lists what the shop would pay for the local player's items,
+// sorted by name. Items without a price can't be sold, nor can keys the
+// player may still need.
+func (gameStruct *Game) sellOffers() []Offer {
+	shop := gameStruct.shopping.shop
+	counts := make(map[string]int)
+	for _, item := range gameStruct.CurrentLevel.Player.Items {
+		counts[item.Name]++
+	}
+	keys := gameStruct.keyItems()
+	offers := make([]Offer, 0, len(counts))
+	for name, count := range counts {
+		info := gameStruct.Economy.item(name)
+		if info == nil || keys[name] {
+			continue
+		}
+		offers = append(offers, Offer{Item: name, Price: info.Price * shop.Buys / 100, Count: count})
+	}
+	sort.Slice(offers, func(i, j int) bool { return offers[i].Item < offers[j].Item })
+	return offers
+}
+
+// keyItems is the items that open portals or that dialogues ask the player
+// for.
+func (gameStruct *Game) keyItems() map[string]bool {
+	keys := make(map[string]bool)
+	for _, level := range gameStruct.Levels {
+		for _, portal := range level.Portals {
+			if portal.Requires != "" {
+				keys[portal.Requires] = true
+			}
+		}
+	}
+	asks := func(cond *Condition) {
+		if cond != nil && cond.Item != "" {
+			keys[cond.Item] = true
+		}
+	}
+	for _, dialogue := range gameStruct.Story.Dialogues {
+		for _, greeting := range dialogue.Greetings {
+			asks(greeting.If)
+		}
+		for _, node := range dialogue.Nodes {
+			for _, choice := range node.Choices {
+				asks(choice.If)
+			}
+		}
+	}
+	return keys
+}
+
+// trade handles the local player's input while they are in a shop. Buy and
+// Sell trade the offer at Choice, and anything else leaves.
+func (gameStruct *Game) trade(input *Input) {
+	level := gameStruct.CurrentLevel
+	p := &level.Player
+	shop := gameStruct.shopping.shop
+	switch input.Type {
+	case Buy:
+		view := gameStruct.shopView()
+		if input.Choice < 0 || input.Choice >= len(view.Buy) {
+			return
+		}
+		offer := view.Buy[input.Choice]
+		switch {
+		case offer.Count == 0:
+			level.AddEvent(offer.Item + " is sold out")
+		case p.Gold < offer.Price:
+			level.AddEvent("Not enough gold for " + offer.Item)
+		default:
+			p.Gold -= offer.Price
+			for i := range shop.Stock {
+				if shop.Stock[i].Item == offer.Item {
+					shop.Stock[i].Count--
+					break
+				}
+			}
+			gameStruct.receive(p, offer.Item)
+			level.AddEvent("Bought " + offer.Item + " for " + strconv.Itoa(offer.Price))
+		}
+	case Sell:
+		offers := gameStruct.sellOffers()
+		if input.Choice < 0 || input.Choice >= len(offers) {
+			return
+		}
+		offer := offers[input.Choice]
+		p.take(offer.Item)
+		p.Gold += offer.Price
+		for i := range shop.Stock {
+			if shop.Stock[i].Item == offer.Item {
+				shop.Stock[i].Count++
+				break
+			}
+		}
+		level.AddEvent("Sold " + offer.Item + " for " + strconv.Itoa(offer.Price))
+	case CloseWindow:
+		gameStruct.handleInput(input)
+	default:
+		gameStruct.shopping = nil
+	}
+}
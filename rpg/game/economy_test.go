@@ -0,0 +1,137 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+)
+
+var testEconomy = &Economy{
+	Items: []ItemInfo{
+		{Name: "Draught", Price: 8, HP: 10},
+		{Name: "Lantern", Price: 25},
+		{Name: "Key", Price: 30},
+		{Name: "Letter", Price: 2},
+	},
+	Drops: []Drop{{Monster: "Rat", Min: 3, Max: 3, Items: []ItemDrop{{Item: "Draught", Chance: 100}}}},
+	Shops: []*Shop{{Name: "cellar", Title: "Cellar", Buys: 50, Stock: []Stock{
+		{Item: "Draught", Count: 1, Max: 2, Restock: 5},
+		{Item: "Lantern", Count: 1, Max: 1},
+	}}},
+}
+
+// newShopGame has a trader left of the player and a portal to the right that
+// needs the key. The hermit further on wants to see a letter.
+func newShopGame(t *testing.T) *testGame {
+	g := newTestGame(t, "a", map[string]string{"a": `
+		#######
+		#.@.R.#
+		#######
+	`})
+	g.content(economyFile, testEconomy)
+	g.content(storyFile, &Story{
+		NPCs: []NPCInfo{
+			{Name: "Trader", Level: "a", X: 1, Y: 1, Shop: "cellar"},
+			{Name: "Hermit", Level: "a", X: 5, Y: 1, Dialogue: "hermit"},
+		},
+		Dialogues: map[string]*Dialogue{"hermit": {
+			Greetings: []Greeting{{If: &Condition{Item: "Letter"}, Node: "letter"}},
+			Nodes:     map[string]*DialogueNode{"letter": {Text: "A letter!"}},
+		}},
+	})
+	level := g.Levels["a"]
+	level.Portals[Pos{3, 1}] = &LevelPos{Level: level, Pos: Pos{1, 1}, Requires: "Key"}
+	return g
+}
+
+func TestBuy(t *testing.T) {
+	g := newShopGame(t)
+	p := g.player()
+	p.Gold = 30
+
+	g.play(Left)
+	view := g.shopView()
+	if view == nil {
+		t.Fatalf("shop not open")
+	}
+	want := []Offer{{Item: "Draught", Price: 8, Count: 1}, {Item: "Lantern", Price: 25, Count: 1}}
+	if !reflect.DeepEqual(view.Buy, want) {
+		t.Errorf("shop sells %+v, want %+v", view.Buy, want)
+	}
+
+	g.Step(&Input{Type: Buy, Choice: 0})
+	g.wantEvent("Bought Draught for 8")
+	if p.Gold != 22 || p.HP != 30 || len(p.Items) != 0 {
+		t.Errorf("gold %d, HP %d, items %v after drinking the draught", p.Gold, p.HP, p.Items)
+	}
+	g.Step(&Input{Type: Buy, Choice: 0})
+	g.wantEvent("Draught is sold out")
+	g.Step(&Input{Type: Buy, Choice: 1})
+	g.wantEvent("Not enough gold for Lantern")
+	if p.Gold != 22 || g.Economy.Shops[0].Stock[1].Count != 1 {
+		t.Errorf("gold %d and %d lanterns left after failing to buy", p.Gold, g.Economy.Shops[0].Stock[1].Count)
+	}
+
+	g.Step(&Input{Type: Search})
+	if g.shopView() != nil {
+		t.Errorf("still in the shop")
+	}
+}
+
+func TestSell(t *testing.T) {
+	g := newShopGame(t)
+	p := g.player()
+	p.give("Lantern")
+	p.give("Key")
+	p.give("Letter")
+	g.Economy.Shops[0].Stock[1].Count = 0
+
+	g.play(Left)
+	// The key opens the portal and the hermit asks for the letter, so
+	// neither is for sale.
+	want := []Offer{{Item: "Lantern", Price: 12, Count: 1}}
+	if !reflect.DeepEqual(g.shopView().Sell, want) {
+		t.Fatalf("shop buys %+v, want %+v", g.shopView().Sell, want)
+	}
+	g.Step(&Input{Type: Sell, Choice: 0})
+	g.wantEvent("Sold Lantern for 12")
+	if p.Gold != 12 || p.hasItem("Lantern") || g.Economy.Shops[0].Stock[1].Count != 1 {
+		t.Errorf("gold %d, items %v and %d lanterns in stock after selling", p.Gold, p.Items, g.Economy.Shops[0].Stock[1].Count)
+	}
+	g.Step(&Input{Type: Sell, Choice: 0})
+	if p.Gold != 12 || !p.hasItem("Key") || !p.hasItem("Letter") {
+		t.Errorf("gold %d, items %v after selling what can't be sold", p.Gold, p.Items)
+	}
+}
+
+func TestRestock(t *testing.T) {
+	g := newShopGame(t)
+	stock := &g.Economy.Shops[0].Stock[0]
+	stock.Count = 0
+	for turn := 1; turn <= 20; turn++ {
+		g.Economy.restock(turn)
+		if want := turn / 5; want <= 2 && stock.Count != want {
+			t.Fatalf("%d in stock on turn %d, want %d", stock.Count, turn, want)
+		}
+	}
+	if stock.Count != 2 {
+		t.Errorf("%d in stock, want no more than 2", stock.Count)
+	}
+}
+
+func TestDrop(t *testing.T) {
+	g := newShopGame(t)
+	g.CurrentLevel.Monsters[Pos{4, 1}].HP = 1
+	g.Levels["a"].Portals = make(map[Pos]*LevelPos)
+
+	g.play(Right, Right)
+	loot := g.CurrentLevel.Loot[Pos{4, 1}]
+	if loot == nil || loot.Gold != 3 || !reflect.DeepEqual(loot.Items, []string{"Draught"}) {
+		t.Fatalf("rat dropped %+v, want 3 gold and a draught", loot)
+	}
+
+	g.play(Right)
+	p := g.player()
+	if p.Gold != 3 || p.HP != 30 {
+		t.Errorf("gold %d and HP %d after picking up the drop", p.Gold, p.HP)
+	}
+}
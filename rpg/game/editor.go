@@ -0,0 +1,198 @@
+package game
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Edit is the payload of the Edit* inputs sent by the map editor. Pos is the
+// tile being edited on the current level. For EditPortal, Level and Pos name
+// the other end of the portal and Target is the tile on the current level.
+type Edit struct {
+	Pos    Pos
+	Rune   rune
+	Level  string
+	Target Pos
+}
+
+func isEdit(t InputType) bool {
+	switch t {
+	case EditTile, EditPortal, EditRemovePortal, EditPrevLevel, EditNextLevel, SaveWorld, CloseEditor:
+		return true
+	}
+	return false
+}
+
+// placeRune sets the tile at pos from a .map file character, adding or
+// removing monsters and the player start as needed. Moving the start
+// doesn't move the player. It reports false for
+// characters that can't appear in a map.
+func (level *Level) placeRune(pos Pos, character rune) bool {
+	var t Tile
+	switch character {
+	case ' ', '\t', '\n', '\r':
+		t.Rune = Blank
+		t.OverlayRune = Blank
+	case '#':
+		t.Rune = StoneWall
+	case '|':
+		t.OverlayRune = ClosedDoor
+		t.Rune = Pending
+	case '/':
+		t.OverlayRune = OpenDoor
+		t.Rune = Pending
+	case 'u':
+		t.OverlayRune = UpStair
+		t.Rune = Pending
+	case 'd':
+		t.OverlayRune = DownStair
+		t.Rune = Pending
+	case '.':
+		t.Rune = DirtFloor
+	case '@':
+		if level.HasStart && level.Start != pos {
+			level.layout[level.Start.Y][level.Start.X] = '.'
+		}
+		level.Start = pos
+		level.HasStart = true
+		t.Rune = Pending
+	case 'R':
+		level.addMonster("Rat", pos)
+		t.Rune = Pending
+	case 'S':
+		level.addMonster("Spider", pos)
+		t.Rune = Pending
+	default:
+		return false
+	}
+	if character != 'R' && character != 'S' {
+		delete(level.Monsters, pos)
+	}
+	if character != '@' && level.HasStart && level.Start == pos {
+		level.HasStart = false
+	}
+	level.Map[pos.Y][pos.X] = t
+	if t.Rune == Blank {
+		character = ' '
+	}
+	level.layout[pos.Y][pos.X] = character
+	return true
+}
+
+// setRune changes the tile at pos of a loaded level, as placeRune does
+// while one is being parsed.
+func (level *Level) setRune(pos Pos, character rune) bool {
+	if !level.placeRune(pos, character) {
+		return false
+	}
+	if level.Map[pos.Y][pos.X].Rune == Pending {
+		level.Map[pos.Y][pos.X].Rune = level.bfsFloor(pos)
+	}
+	level.lineOfSight()
+	return true
+}
+
+// WriteMap writes the level as it was loaded and edited, in the format
+// loadLevels reads: monsters where they started, doors as they were. Trailing
+// blanks are trimmed from every row.
+func (level *Level) WriteMap(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, line := range level.layout {
+		end := len(line)
+		for end > 0 && line[end-1] == ' ' {
+			end--
+		}
+		bw.WriteString(string(line[:end]))
+		bw.WriteString("\n")
+	}
+	return bw.Flush()
+}
+
+func (gameStruct *Game) saveWorld() error {
+	for name, level := range gameStruct.Levels {
+		file, err := os.Create(filepath.Join(gameStruct.dir, name+".map"))
+		if err != nil {
+			return err
+		}
+		err = level.WriteMap(file)
+		file.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	file, err := os.Create(filepath.Join(gameStruct.dir, worldFile))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return gameStruct.World().Write(file)
+}
+
+func (gameStruct *Game) applyEdit(input *Input) {
+	gameStruct.clearTurnEvents()
+	if input.Type == CloseEditor {
+		gameStruct.editing = nil
+		return
+	}
+	if gameStruct.editing == nil {
+		gameStruct.editing = gameStruct.CurrentLevel
+	}
+	level := gameStruct.editing
+	switch input.Type {
+	case EditTile:
+		pos := input.Edit.Pos
+		if !inRange(level, pos) {
+			return
+		}
+		level.setRune(pos, input.Edit.Rune)
+
+	case EditPortal:
+		from := gameStruct.Levels[input.Edit.Level]
+		if from == nil || !inRange(from, input.Edit.Pos) || !inRange(level, input.Edit.Target) {
+			return
+		}
+		from.Portals[input.Edit.Pos] = &LevelPos{Level: level, Pos: input.Edit.Target}
+		level.Portals[input.Edit.Target] = &LevelPos{Level: from, Pos: input.Edit.Pos}
+		level.AddEvent("Linked portal to " + from.Name)
+
+	case EditRemovePortal:
+		pos := input.Edit.Pos
+		dest := level.Portals[pos]
+		if dest == nil {
+			return
+		}
+		delete(level.Portals, pos)
+		back := dest.Level.Portals[dest.Pos]
+		if back != nil && back.Level == level && back.Pos == pos {
+			delete(dest.Level.Portals, dest.Pos)
+		}
+		level.AddEvent("Removed portal")
+
+	case EditPrevLevel, EditNextLevel:
+		names := make([]string, 0, len(gameStruct.Levels))
+		for name := range gameStruct.Levels {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		i := sort.SearchStrings(names, level.Name)
+		if input.Type == EditNextLevel {
+			i = (i + 1) % len(names)
+		} else {
+			i = (i + len(names) - 1) % len(names)
+		}
+		gameStruct.editing = gameStruct.Levels[names[i]]
+
+	case SaveWorld:
+		err := gameStruct.saveWorld()
+		if err != nil {
+			level.AddEvent("Save failed: " + err.Error())
+		} else {
+			level.AddEvent("Saved world")
+		}
+	}
+	gameStruct.updateLight()
+}
@@ -0,0 +1,136 @@
+package game
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func writeMap(t *testing.T, level *Level) string {
+	t.Helper()
+	var buf bytes.Buffer
+	err := level.WriteMap(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestWriteMapRoundTrip(t *testing.T) {
+	lines := mapLines(`
+		  #######
+		  #@.|.R##
+		###.u/.#
+		#d.S.#
+		#####
+	`)
+	level, invalid := parseLevel("a", lines, newPlayer(), &characterIDs{})
+	if len(invalid) > 0 {
+		t.Fatalf("invalid characters at %v", invalid)
+	}
+	want := strings.Join(lines, "\n") + "\n"
+	got := writeMap(t, level)
+	if got != want {
+		t.Fatalf("wrote\n%s\nwant\n%s", got, want)
+	}
+
+	again, _ := parseLevel("a", strings.Split(strings.TrimSuffix(got, "\n"), "\n"), newPlayer(), &characterIDs{})
+	if writeMap(t, again) != want {
+		t.Errorf("map changed when read back")
+	}
+}
+
+func TestWriteMapLeavesOutPlay(t *testing.T) {
+	m := `
+		#########
+		#@.|..R.#
+		#########
+	`
+	g := newTestGame(t, "a", map[string]string{"a": m})
+	// The player opens the door and the rat comes to fight them.
+	g.play(Right, Right, Right, Right)
+	if g.CurrentLevel.Map[1][3].OverlayRune != OpenDoor {
+		t.Fatalf("door not opened")
+	}
+	if g.CurrentLevel.Monsters[Pos{6, 1}] != nil {
+		t.Fatalf("rat didn't move")
+	}
+	g.applyEdit(&Input{Type: EditTile, Edit: &Edit{Pos: Pos{7, 1}, Rune: '#'}})
+
+	want := strings.Join(mapLines(m), "\n") + "\n"
+	want = strings.Replace(want, "R.#", "R##", 1)
+	got := writeMap(t, g.CurrentLevel)
+	if got != want {
+		t.Errorf("wrote\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWriteMapMovesStart(t *testing.T) {
+	g := newTestGame(t, "a", map[string]string{"a": `
+		#####
+		#@..#
+		#####
+	`})
+	g.applyEdit(&Input{Type: EditTile, Edit: &Edit{Pos: Pos{3, 1}, Rune: '@'}})
+	want := "#####\n#..@#\n#####\n"
+	got := writeMap(t, g.CurrentLevel)
+	if got != want {
+		t.Errorf("wrote\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestEditorBrowsingLeavesPlayer(t *testing.T) {
+	g := newTestGame(t, "a", map[string]string{
+		"a": `
+			#####
+			#@..#
+			#####
+		`,
+		"b": `
+			#####
+			#..@#
+			#####
+		`,
+	})
+	g.play(Right)
+	p := g.player()
+	p.HP = 7
+	p.give("Key")
+	levelChan := make(chan *Snapshot, 1)
+	g.LevelChans = []chan *Snapshot{levelChan}
+
+	g.applyEdit(&Input{Type: EditNextLevel})
+	g.applyEdit(&Input{Type: EditTile, Edit: &Edit{Pos: Pos{1, 1}, Rune: '@'}})
+	g.publish()
+	if snap := <-levelChan; snap.Name != "b" {
+		t.Errorf("editor shows %s, want b", snap.Name)
+	}
+
+	g.applyEdit(&Input{Type: CloseEditor})
+	g.publish()
+	if snap := <-levelChan; snap.Name != "a" {
+		t.Errorf("closed editor shows %s, want a", snap.Name)
+	}
+	g.wantPlayerAt("a", Pos{2, 1})
+	p = g.player()
+	if p.HP != 7 || !p.hasItem("Key") {
+		t.Errorf("player has %d HP and %v after editing, want 7 and the key", p.HP, p.Items)
+	}
+	if b := g.Levels["b"]; b.Start != (Pos{1, 1}) || b.Player.Pos != (Pos{3, 1}) {
+		t.Errorf("b starts at %v with its player at %v, want 1,1 and 3,1", b.Start, b.Player.Pos)
+	}
+}
+
+func TestEditorMovingStartLeavesPlayer(t *testing.T) {
+	g := newTestGame(t, "a", map[string]string{"a": `
+		#####
+		#@..#
+		#####
+	`})
+	g.play(Right)
+	g.applyEdit(&Input{Type: EditTile, Edit: &Edit{Pos: Pos{3, 1}, Rune: '@'}})
+	g.wantPlayerAt("a", Pos{2, 1})
+	if g.CurrentLevel.Start != (Pos{3, 1}) || !g.CurrentLevel.HasStart {
+		t.Errorf("start at %v, want 3,1", g.CurrentLevel.Start)
+	}
+}
@@ -2,37 +2,133 @@ package game
 
 import (
 	"bufio"
-	"encoding/csv"
-	"fmt"
 	"math"
+	"math/rand"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/LucasK1/gameswithgo/pathfind"
 )
 
+const mapDir = "/home/lucask/go-dev/src/github.com/LucasK1/gameswithgo/rpg/game/maps"
+
 type Game struct {
-	LevelChans   []chan *Level
+	LevelChans   []chan *Snapshot
 	InputChan    chan *Input
 	Levels       map[string]*Level
 	CurrentLevel *Level
+	StartLevel   *Level
+	Local        bool
+	Remotes      map[int]*RemotePlayer
+	Story        *Story
+	Quests       *QuestLog
+	Economy      *Economy
+	// Turn counts the turns played, which is what shops restock by.
+	Turn     int
+	Stats    *RunStats
+	talk     *conversation
+	shopping *shopping
+	travel   *travel
+	// editing is the level open in the map editor, which is published
+	// instead of CurrentLevel until the editor is closed. Browsing to other
+	// levels in the editor leaves the player where they are.
+	editing *Level
+	// rand is where everything left to chance in the game comes from.
+	rand *rand.Rand
+	// ids numbers the game's monsters and remote players. It isn't reset
+	// with a new run, as remote players carry on into it.
+	ids *characterIDs
+	// dir is where the game is read from and writes its saves, high scores
+	// and morgue files: mapDir, but for tests.
+	dir string
+	// saves is whether the game picks up from save.json and writes it on
+	// quitting, and keeps the high scores and morgue files.
+	saves bool
+	// paused is set while the player is in a menu, when no turns are played.
+	// played is whether the player has played, or started or loaded a game,
+	// since this one was made, which is when there is something to save.
+	paused bool
+	played bool
 }
 
+// NewGame starts a game for numWindows windows on this computer, or for
+// remote players only if there are none. A game with windows carries on from
+// save.json and waits, paused, for the player to pick what to play from the
+// menu.
 func NewGame(numWindows int) *Game {
-	levelChans := make([]chan *Level, numWindows)
+	gameStruct := newGame(numWindows, time.Now().UnixNano(), mapDir)
+	if gameStruct.Local {
+		gameStruct.saves = true
+		gameStruct.paused = true
+		gameStruct.loadSave(saveFile)
+	}
+	gameStruct.lightLevels()
+	return gameStruct
+}
+
+// NewPlaytestGame starts a game for one window that always plays out the same
+// way from the same seed and the same inputs, and leaves save.json alone.
+func NewPlaytestGame(seed int64) *Game {
+	gameStruct := newGame(1, seed, mapDir)
+	gameStruct.lightLevels()
+	return gameStruct
+}
+
+func newGame(numWindows int, seed int64, dir string) *Game {
+	levelChans := make([]chan *Snapshot, numWindows)
 	for i := range levelChans {
-		levelChans[i] = make(chan *Level)
+		levelChans[i] = make(chan *Snapshot)
 	}
 	inputChan := make(chan *Input)
 
-	levels := loadLevels()
-
-	gameStruct := &Game{LevelChans: levelChans, InputChan: inputChan, Levels: levels, CurrentLevel: nil}
+	gameStruct := &Game{LevelChans: levelChans, InputChan: inputChan, dir: dir}
+	gameStruct.Local = numWindows > 0
+	gameStruct.Remotes = make(map[int]*RemotePlayer)
+	gameStruct.rand = rand.New(rand.NewSource(seed))
+	gameStruct.ids = &characterIDs{}
+	gameStruct.begin()
+	return gameStruct
+}
 
-	gameStruct.loadWorldFile()
-	gameStruct.CurrentLevel.lineOfSight()
+// begin sets the game up from scratch from the files in its dir.
+func (gameStruct *Game) begin() {
+	levels := loadLevels(gameStruct.dir, gameStruct.ids)
+	gameStruct.Levels = levels
+	gameStruct.Turn = 0
+	gameStruct.Stats = newRunStats()
+	gameStruct.talk = nil
+	gameStruct.shopping = nil
+	gameStruct.travel = nil
+	gameStruct.editing = nil
+
+	gameStruct.loadWorldFile(gameStruct.dir)
+	scripts, err := loadScripts(gameStruct.dir)
+	if err != nil {
+		panic(err)
+	}
+	if scripts != nil {
+		scripts.players = gameStruct.playersOn
+	}
+	for _, level := range levels {
+		level.scripts = scripts
+	}
 
-	return gameStruct
+	gameStruct.Story, err = readStory(gameStruct.dir)
+	if err != nil {
+		panic(err)
+	}
+	gameStruct.Story.placeNPCs(levels)
+	gameStruct.Economy, err = readEconomy(gameStruct.dir)
+	if err != nil {
+		panic(err)
+	}
+	gameStruct.Quests = newQuestLog(gameStruct.Story.Quests)
+	for _, level := range levels {
+		level.quests = gameStruct.Quests
+	}
 }
 
 type InputType int
@@ -46,11 +142,36 @@ const (
 	QuitGame
 	CloseWindow
 	Search
+	EditTile
+	EditPortal
+	EditRemovePortal
+	EditPrevLevel
+	EditNextLevel
+	SaveWorld
+	CloseEditor
+	Choose
+	Buy
+	Sell
+	Pause
+	Resume
+	NewRun
+	LoadGame
+	SaveGame
+	Travel
 )
 
 type Input struct {
 	Type         InputType
-	LevelChannel chan *Level
+	LevelChannel chan *Snapshot
+	PlayerID     int
+	Edit         *Edit
+	// Choice is the index of the dialogue choice picked by Choose, or -1 to
+	// leave the conversation, or of the offer taken by Buy or Sell.
+	Choice int
+	// Slot is the save slot for LoadGame and SaveGame, 0 being save.json.
+	Slot int
+	// Pos is the tile Travel walks to.
+	Pos Pos
 }
 
 type Tile struct {
@@ -58,6 +179,8 @@ type Tile struct {
 	OverlayRune rune
 	Visible     bool
 	Seen        bool
+	// Light is how brightly, and in what colour, the tile is lit.
+	Light Light
 }
 
 const (
@@ -78,6 +201,7 @@ type Pos struct {
 type LevelPos struct {
 	*Level
 	Pos
+	Requires string
 }
 
 type Entity struct {
@@ -88,15 +212,25 @@ type Entity struct {
 
 type Character struct {
 	Entity
+	// ID tells characters apart where their names don't: the local player's
+	// is 0, and monsters and remote players are numbered together from 1.
+	ID         int
 	HP         int
 	Strength   int
 	Speed      float64
 	AP         float64
 	SightRange int
+	// Light is the radius of the light the character carries or gives off,
+	// in LightColor. DarkVision is how far they see without any light.
+	Light      int
+	LightColor Light
+	DarkVision int
 }
 
 type Player struct {
 	Character
+	Items []*Item
+	Gold  int
 }
 
 type GameEvent int
@@ -107,17 +241,67 @@ const (
 	Attack
 	Hit
 	Portal
+	Death
 )
 
+// TurnEvent is one thing that happened during a turn. Actor and Target are
+// the names of the characters involved and ActorID and TargetID their IDs,
+// Nobody if there is no such character. Pos is where it happened and Damage
+// is how much HP a Hit took.
+type TurnEvent struct {
+	Type     GameEvent
+	Actor    string
+	ActorID  int
+	Target   string
+	TargetID int
+	Pos      Pos
+	Damage   int
+}
+
+// Nobody is the ID in a TurnEvent of a character who isn't there, such as
+// the actor of a move or the killer of a monster that died by itself.
+const Nobody = -1
+
 type Level struct {
-	Map       [][]Tile
-	Player    Player
-	Monsters  map[Pos]*Monster
-	Portals   map[Pos]*LevelPos
-	Events    []string
-	EventPos  int
-	LastEvent GameEvent
-	Debug     map[Pos]bool
+	Name   string
+	Title  string
+	Depth  int
+	Music  string
+	Spawns []Spawn
+	// SpawnEvery, MaxMonsters and Spawners are as in LevelInfo.
+	SpawnEvery  int
+	MaxMonsters int
+	Spawners    []Spawner
+	Map         [][]Tile
+	Player      Player
+	Monsters    map[Pos]*Monster
+	// Corpses and Loot are what killed monsters left behind.
+	Corpses  map[Pos]*Corpse
+	Loot     map[Pos]*Loot
+	NPCs     map[Pos]*NPC
+	Portals  map[Pos]*LevelPos
+	Events   []string
+	EventPos int
+	// TurnEvents is everything that happened on the level during the last
+	// turn.
+	TurnEvents []TurnEvent
+	Debug      map[Pos]bool
+	Start      Pos
+	HasStart   bool
+	// Ambient lights every tile of the level; Lights are its braziers and
+	// other fixed lights.
+	Ambient Light
+	Lights  []LightSource
+
+	scripts *scripts
+	quests  *QuestLog
+	// actor is the character who set off the script hook running, if any.
+	actor *Character
+	// ids numbers the characters of the game the level is in.
+	ids *characterIDs
+	// layout is the level as written in its .map file and changed in the
+	// editor, without what has happened since it was loaded.
+	layout [][]rune
 }
 
 func (level *Level) Attack(c1, c2 *Character) {
@@ -125,11 +309,38 @@ func (level *Level) Attack(c1, c2 *Character) {
 	c1AttackPower := c1.Strength
 	c2.HP -= c1AttackPower
 
+	level.emit(TurnEvent{Type: Attack, Actor: c1.Name, ActorID: c1.ID, Target: c2.Name, TargetID: c2.ID, Pos: c2.Pos})
+	if c1AttackPower > 0 {
+		level.emit(TurnEvent{Type: Hit, Actor: c1.Name, ActorID: c1.ID, Target: c2.Name, TargetID: c2.ID, Pos: c2.Pos, Damage: c1AttackPower})
+	}
+
 	if c2.HP > 0 {
 		level.AddEvent(c1.Name + " attacked " + c2.Name + " for " + strconv.Itoa(c1AttackPower))
 	} else {
-		level.AddEvent(c1.Name + " killed " + c2.Name)
+		level.died(c1, c2)
+	}
+}
+
+// died is where every death goes, whether c was killed in a fight or by a
+// script: it is logged and the kill hooks and quests hear of it. killer is
+// nil when nobody is to blame. A dead monster lies where it fell until the
+// game buries it.
+func (level *Level) died(killer, c *Character) {
+	if killer == nil {
+		level.AddEvent(c.Name + " died")
+		level.emit(TurnEvent{Type: Death, ActorID: Nobody, Target: c.Name, TargetID: c.ID, Pos: c.Pos})
+	} else {
+		level.AddEvent(killer.Name + " killed " + c.Name)
+		level.emit(TurnEvent{Type: Death, Actor: killer.Name, ActorID: killer.ID, Target: c.Name, TargetID: c.ID, Pos: c.Pos})
 	}
+	level.scripts.killed(level, killer, c)
+	if killer == &level.Player.Character {
+		level.quests.killed(c.Name, level)
+	}
+}
+
+func (level *Level) emit(event TurnEvent) {
+	level.TurnEvents = append(level.TurnEvents, event)
 }
 
 func (level *Level) AddEvent(event string) {
@@ -141,22 +352,40 @@ func (level *Level) AddEvent(event string) {
 }
 
 func (level *Level) lineOfSight() {
-	pos := level.Player.Pos
-	dist := level.Player.SightRange
+	level.fieldOfView(level.Player.Pos, level.Player.SightRange, func(pos Pos) {
+		if level.canSee(&level.Player.Character, pos) {
+			level.Map[pos.Y][pos.X].Visible = true
+			level.Map[pos.Y][pos.X].Seen = true
+		}
+	})
+}
 
+// FieldOfView returns the tiles viewer can see without touching the Visible
+// and Seen flags on the map, which belong to the local player.
+func (level *Level) FieldOfView(viewer *Character) map[Pos]bool {
+	visible := make(map[Pos]bool)
+	level.fieldOfView(viewer.Pos, viewer.SightRange, func(p Pos) {
+		if level.canSee(viewer, p) {
+			visible[p] = true
+		}
+	})
+	return visible
+}
+
+func (level *Level) fieldOfView(pos Pos, dist int, mark func(Pos)) {
 	for y := pos.Y - dist; y <= pos.Y+dist; y++ {
 		for x := pos.X - dist; x <= pos.X+dist; x++ {
 			xDelta := pos.X - x
 			yDelta := pos.Y - y
 			d := math.Sqrt(float64(xDelta*xDelta + yDelta*yDelta))
 			if d <= float64(dist) {
-				level.bresenham(pos, Pos{x, y})
+				level.bresenham(pos, Pos{x, y}, mark)
 			}
 		}
 	}
 }
 
-func (level *Level) bresenham(start, end Pos) {
+func (level *Level) bresenham(start, end Pos, mark func(Pos)) {
 	steep := math.Abs(float64(end.Y-start.Y)) > math.Abs(float64(end.X-start.X))
 
 	if steep {
@@ -184,8 +413,7 @@ func (level *Level) bresenham(start, end Pos) {
 				pos = Pos{X: x, Y: y}
 
 			}
-			level.Map[pos.Y][pos.X].Visible = true
-			level.Map[pos.Y][pos.X].Seen = true
+			mark(pos)
 			if !canSeeThrough(level, pos) {
 				return
 			}
@@ -207,8 +435,7 @@ func (level *Level) bresenham(start, end Pos) {
 				pos = Pos{X: x, Y: y}
 
 			}
-			level.Map[pos.Y][pos.X].Visible = true
-			level.Map[pos.Y][pos.X].Seen = true
+			mark(pos)
 			if !canSeeThrough(level, pos) {
 				return
 			}
@@ -221,77 +448,96 @@ func (level *Level) bresenham(start, end Pos) {
 	}
 }
 
-func (gameStruct *Game) loadWorldFile() {
-	file, err := os.Open("/home/lucask/go-dev/src/github.com/LucasK1/gameswithgo/rpg/game/maps/world.txt")
+func newPlayer() *Player {
+	player := &Player{}
+	player.Name = "Dralanor"
+	player.Rune = '@'
+	player.HP = 20
+	player.Strength = 20
+	player.Speed = 1
+	player.AP = 0
+	player.SightRange = 7
+	player.Light = 5
+	player.LightColor = torchLight
+	player.DarkVision = 1
+	return player
+}
+
+func readMapFile(levelpath string) ([]string, error) {
+	file, err := os.Open(levelpath)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
+	defer file.Close()
 
-	csvReader := csv.NewReader(file)
-	csvReader.FieldsPerRecord = -1
-	csvReader.TrimLeadingSpace = true
-	rows, err := csvReader.ReadAll()
-	if err != nil {
-		panic(err)
+	scanner := bufio.NewScanner(file)
+	levelLines := make([]string, 0)
+	for scanner.Scan() {
+		levelLines = append(levelLines, scanner.Text())
 	}
-	for rowIndex, row := range rows {
-		if rowIndex == 0 {
-			gameStruct.CurrentLevel = gameStruct.Levels[row[0]]
-			if gameStruct.CurrentLevel == nil {
-				fmt.Println("Couldn't find current level name in the world file")
-				panic(nil)
-			}
-			continue
-		}
-		levelWithPortal := gameStruct.Levels[row[0]]
-		if levelWithPortal == nil {
-			fmt.Println("Couldn't find level name 1 in the world file")
-			panic(nil)
-		}
-		x, err := strconv.ParseInt(row[1], 10, 64)
-		if err != nil {
-			panic(err)
-		}
-		y, err := strconv.ParseInt(row[2], 10, 64)
-		if err != nil {
-			panic(err)
-		}
-		pos := Pos{X: int(x), Y: int(y)}
+	return levelLines, scanner.Err()
+}
 
-		levelToTeleportTo := gameStruct.Levels[row[3]]
-		if levelToTeleportTo == nil {
-			fmt.Println("Couldn't find level name 2 in the world file")
-			panic(nil)
+// parseLevel builds a level from the lines of a .map file. Characters that
+// can't appear in a map are left blank and their positions returned.
+func parseLevel(levelName string, levelLines []string, player *Player, ids *characterIDs) (*Level, []Pos) {
+	longestRow := 0
+	for _, line := range levelLines {
+		if len(line) > longestRow {
+			longestRow = len(line)
 		}
-		x, err = strconv.ParseInt(row[4], 10, 64)
-		if err != nil {
-			panic(err)
+	}
+
+	level := &Level{Name: levelName, Ambient: Daylight, ids: ids}
+	// level.Debug = make(map[Pos]bool, 0)
+	level.Events = make([]string, 10)
+	level.Player = *player
+	level.Map = make([][]Tile, len(levelLines))
+	level.Monsters = make(map[Pos]*Monster)
+	level.Corpses = make(map[Pos]*Corpse)
+	level.Loot = make(map[Pos]*Loot)
+	level.NPCs = make(map[Pos]*NPC)
+	level.Portals = make(map[Pos]*LevelPos)
+
+	level.layout = make([][]rune, len(levelLines))
+	for i := range level.Map {
+		level.Map[i] = make([]Tile, longestRow)
+		level.layout[i] = make([]rune, longestRow)
+		for x := range level.layout[i] {
+			level.layout[i][x] = ' '
 		}
-		y, err = strconv.ParseInt(row[5], 10, 64)
-		if err != nil {
-			panic(err)
+	}
+
+	invalid := make([]Pos, 0)
+	for y, line := range levelLines {
+		for x, character := range line {
+			if !level.placeRune(Pos{x, y}, character) {
+				invalid = append(invalid, Pos{x, y})
+			}
 		}
-		posToTeleportTo := Pos{X: int(x), Y: int(y)}
+	}
 
-		levelWithPortal.Portals[pos] = &LevelPos{levelToTeleportTo, posToTeleportTo}
+	if level.HasStart {
+		level.Player.Pos = level.Start
+	}
+
+	for y, row := range level.Map {
+		for x, tile := range row {
+			if tile.Rune == Pending {
 
+				level.Map[y][x].Rune = level.bfsFloor(Pos{x, y})
+			}
+		}
 	}
+	return level, invalid
 }
 
-func loadLevels() map[string]*Level {
-
-	player := &Player{}
-	player.Name = "Dralanor"
-	player.Rune = '@'
-	player.HP = 20
-	player.Strength = 20
-	player.Speed = 1
-	player.AP = 0
-	player.SightRange = 7
+func loadLevels(dir string, ids *characterIDs) map[string]*Level {
+	player := newPlayer()
 
 	levels := make(map[string]*Level)
 
-	levelpaths, err := filepath.Glob("/home/lucask/go-dev/src/github.com/LucasK1/gameswithgo/rpg/game/maps/*.map")
+	levelpaths, err := filepath.Glob(filepath.Join(dir, "*.map"))
 	if err != nil {
 		panic(err)
 	}
@@ -302,83 +548,16 @@ func loadLevels() map[string]*Level {
 		extIndex := strings.LastIndex(levelName, ".map")
 		levelName = levelName[0:extIndex]
 
-		file, err := os.Open(levelpath)
+		levelLines, err := readMapFile(levelpath)
 		if err != nil {
 			panic(err)
 		}
-		defer file.Close()
-
-		scanner := bufio.NewScanner(file)
-		levelLines := make([]string, 0)
-		longestRow := 0
-		index := 0
-		for scanner.Scan() {
-			levelLines = append(levelLines, scanner.Text())
-			if len(levelLines[index]) > longestRow {
-				longestRow = len(levelLines[index])
-			}
-			index++
-		}
-		level := &Level{}
-		// level.Debug = make(map[Pos]bool, 0)
-		level.Events = make([]string, 10)
-		level.Player = *player
-		level.Map = make([][]Tile, len(levelLines))
-		level.Monsters = make(map[Pos]*Monster)
-		level.Portals = make(map[Pos]*LevelPos)
-
-		for i := range level.Map {
-			level.Map[i] = make([]Tile, longestRow)
-		}
 
-		for y, line := range levelLines {
-			for x, character := range line {
-				var t Tile
-				switch character {
-				case ' ', '\t', '\n', '\r':
-					t.Rune = Blank
-					t.OverlayRune = Blank
-				case '#':
-					t.Rune = StoneWall
-				case '|':
-					t.OverlayRune = ClosedDoor
-					t.Rune = Pending
-				case '/':
-					t.OverlayRune = OpenDoor
-					t.Rune = Pending
-				case 'u':
-					t.OverlayRune = UpStair
-					t.Rune = Pending
-				case 'd':
-					t.OverlayRune = DownStair
-					t.Rune = Pending
-				case '.':
-					t.Rune = DirtFloor
-				case '@':
-					level.Player.X = x
-					level.Player.Y = y
-					t.Rune = Pending
-				case 'R':
-					level.Monsters[Pos{x, y}] = NewRat(Pos{x, y})
-					t.Rune = Pending
-				case 'S':
-					level.Monsters[Pos{x, y}] = NewSpider(Pos{x, y})
-					t.Rune = Pending
-				default:
-					panic("Invalid character in map")
-				}
-				level.Map[y][x] = t
-			}
-		}
-
-		for y, row := range level.Map {
-			for x, tile := range row {
-				if tile.Rune == Pending {
-
-					level.Map[y][x].Rune = level.bfsFloor(Pos{x, y})
-				}
-			}
+		level, invalid := parseLevel(levelName, levelLines, player, ids)
+		if len(invalid) > 0 {
+			panic("Invalid character in map")
 		}
+		level.computeLight(nil)
 		level.lineOfSight()
 		levels[levelName] = level
 	}
@@ -401,7 +580,8 @@ func canWalk(level *Level, pos Pos) bool {
 			return false
 		}
 		_, exists := level.Monsters[pos]
-		return !exists
+		_, isNPC := level.NPCs[pos]
+		return !exists && !isNPC
 	}
 	return false
 }
@@ -423,12 +603,12 @@ func canSeeThrough(level *Level, pos Pos) bool {
 	return false
 }
 
-func checkDoor(level *Level, pos Pos) {
+func checkDoor(level *Level, pos Pos, opener *Character) {
 	t := level.Map[pos.Y][pos.X]
 
 	if t.OverlayRune == ClosedDoor {
 		level.Map[pos.Y][pos.X].OverlayRune = OpenDoor
-		level.LastEvent = DoorOpen
+		level.emit(TurnEvent{Type: DoorOpen, Actor: opener.Name, ActorID: opener.ID, TargetID: Nobody, Pos: pos})
 		level.lineOfSight()
 	}
 }
@@ -436,40 +616,50 @@ func checkDoor(level *Level, pos Pos) {
 func (gameStruct *Game) Move(to Pos, level *Level) {
 	levelAndPos := level.Portals[to]
 	if levelAndPos != nil {
+		if !level.Player.canUse(levelAndPos) {
+			level.AddEvent("The way is barred without a " + levelAndPos.Requires)
+			return
+		}
+		// The player takes everything they have to the new level.
 		gameStruct.CurrentLevel = levelAndPos.Level
+		gameStruct.CurrentLevel.Player = level.Player
 		gameStruct.CurrentLevel.Player.Pos = levelAndPos.Pos
+		gameStruct.CurrentLevel.AddEvent("Entered " + gameStruct.CurrentLevel.Title)
+		gameStruct.Quests.visited(gameStruct.CurrentLevel.Name, gameStruct.CurrentLevel)
+		gameStruct.CurrentLevel.emit(TurnEvent{Type: Portal, Actor: level.Player.Name, ActorID: level.Player.ID, TargetID: Nobody, Pos: levelAndPos.Pos})
+		gameStruct.CurrentLevel.scripts.enteredTile(gameStruct.CurrentLevel, &gameStruct.CurrentLevel.Player.Character)
 	} else {
 		level.Player.Pos = to
-		level.LastEvent = Move
-		for y, row := range level.Map {
-			for x := range row {
-				level.Map[y][x].Visible = false
-			}
-		}
-		level.lineOfSight()
+		level.emit(TurnEvent{Type: Move, Actor: level.Player.Name, ActorID: level.Player.ID, TargetID: Nobody, Pos: to})
+		gameStruct.pickUp(&level.Player, level)
+		level.refreshView()
+		level.scripts.enteredTile(level, &level.Player.Character)
 	}
 }
 
 func (gameStruct *Game) resolveMovement(pos Pos) {
 	level := gameStruct.CurrentLevel
 	monster, exists := level.Monsters[pos]
-	if exists {
+	npc, isNPC := level.NPCs[pos]
+	if isNPC {
+		gameStruct.talkTo(npc)
+	} else if exists {
 		level.Attack(&level.Player.Character, &monster.Character)
-		level.LastEvent = Attack
-		if monster.HP <= 0 {
-			delete(level.Monsters, monster.Pos)
-		}
-		if level.Player.HP <= 0 {
-			panic("DEAD")
-		}
 	} else if canWalk(level, pos) {
 		gameStruct.Move(pos, level)
-	} else {
-		checkDoor(level, pos)
+	} else if !level.scripts.interacted(level, &level.Player.Character, pos) {
+		checkDoor(level, pos, &level.Player.Character)
 	}
 }
 
 func (gameStruct *Game) handleInput(input *Input) {
+	if input.PlayerID != 0 {
+		gameStruct.handleRemoteInput(input)
+		return
+	}
+	if input.Type != Travel {
+		gameStruct.travel = nil
+	}
 	level := gameStruct.CurrentLevel
 	p := level.Player
 	switch input.Type {
@@ -489,6 +679,13 @@ func (gameStruct *Game) handleInput(input *Input) {
 		newPos := Pos{p.X + 1, p.Y}
 		gameStruct.resolveMovement(newPos)
 
+	case Search:
+		level.scripts.interacted(level, &level.Player.Character, p.Pos)
+
+	case Travel:
+		gameStruct.resolveMovement(gameStruct.travel.next)
+		gameStruct.travelled(level)
+
 	case CloseWindow:
 		close(input.LevelChannel)
 		chanIndex := 0
@@ -527,90 +724,62 @@ func getNeighbors(level *Level, pos Pos) []Pos {
 }
 
 func (level *Level) bfsFloor(start Pos) rune {
-	frontier := make([]Pos, 0, 8)
-
-	frontier = append(frontier, start)
-
-	visited := make(map[Pos]bool)
-	visited[start] = true
-
-	for len(frontier) > 0 {
-		current := frontier[0]
-		currentTile := level.Map[current.Y][current.X]
-		switch currentTile.Rune {
-		case DirtFloor:
-			return DirtFloor
-		default:
-		}
-
-		frontier = frontier[1:]
-		for _, next := range getNeighbors(level, current) {
-			if !visited[next] {
-				frontier = append(frontier, next)
-				visited[next] = true
-			}
-		}
+	path := pathfind.BFS[Pos](walkGraph{level: level}, start, func(pos Pos) bool {
+		return level.Map[pos.Y][pos.X].Rune == DirtFloor
+	})
+	if path == nil {
+		return DirtFloor
 	}
-	return DirtFloor
+	return level.Map[path[len(path)-1].Y][path[len(path)-1].X].Rune
 }
 
 func (level *Level) astar(start Pos, goal Pos) []Pos {
-	frontier := make(pqueue, 0, 8)
-	frontier = frontier.push(start, 1)
-
-	cameFrom := make(map[Pos]Pos)
-	cameFrom[start] = start
-
-	costSoFar := make(map[Pos]int)
-	costSoFar[start] = 0
-
-	var current Pos
-	for len(frontier) > 0 {
-		frontier, current = frontier.pop()
-
-		if current == goal {
-			path := make([]Pos, 0)
-			p := current
-			for p != start {
-				path = append(path, p)
-				p = cameFrom[p]
-			}
-			path = append(path, p)
-
-			for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
-				path[i], path[j] = path[j], path[i]
-			}
-
-			return path
-		}
-
-		for _, next := range getNeighbors(level, current) {
-			newCost := costSoFar[current] + 1
-			_, exists := costSoFar[next]
-			if !exists || newCost < costSoFar[next] {
-				costSoFar[next] = newCost
-
-				xDist := int(math.Abs(float64(goal.X - next.X)))
-				yDist := int(math.Abs(float64(goal.Y - next.Y)))
+	return pathfind.AStar[Pos](walkGraph{level: level, goal: &goal}, start, goal, func(pos Pos) int {
+		return int(math.Abs(float64(goal.X-pos.X))) + int(math.Abs(float64(goal.Y-pos.Y)))
+	})
+}
 
-				priority := newCost + xDist + yDist
-				frontier = frontier.push(next, priority)
+// walkGraph is the level as a graph of the tiles a character can walk to.
+// A path may end on goal even when something stands there or it is a closed
+// door, so that it leads up to a monster to attack or a door to open.
+type walkGraph struct {
+	level *Level
+	goal  *Pos
+}
 
-				cameFrom[next] = current
-			}
-		}
+func (g walkGraph) Neighbors(pos Pos) []Pos {
+	neighbors := getNeighbors(g.level, pos)
+	goal := g.goal
+	if goal == nil || !inRange(g.level, *goal) || canWalk(g.level, *goal) {
+		return neighbors
+	}
+	if int(math.Abs(float64(goal.X-pos.X)))+int(math.Abs(float64(goal.Y-pos.Y))) != 1 {
+		return neighbors
 	}
-	return nil
+	switch g.level.Map[goal.Y][goal.X].Rune {
+	case StoneWall, Blank:
+		return neighbors
+	}
+	return append(neighbors, *goal)
+}
+
+func (g walkGraph) Cost(from, to Pos) int {
+	return 1
 }
 
 func (gameStruct *Game) Run() {
 
-	for _, lchan := range gameStruct.LevelChans {
-		lchan <- gameStruct.CurrentLevel
-	}
+	gameStruct.publish()
 
 	for input := range gameStruct.InputChan {
 		if input.Type == QuitGame {
+			if gameStruct.saves && gameStruct.played {
+				err := gameStruct.save(saveFile)
+				if err != nil {
+					panic(err)
+				}
+				gameStruct.endRun("quit")
+			}
 			return
 		}
 
@@ -620,18 +789,131 @@ func (gameStruct *Game) Run() {
 		// 	gameStruct.Level.Debug[pos] = true
 		// }
 
-		gameStruct.handleInput(input)
+		switch {
+		case gameStruct.control(input):
+		case gameStruct.paused && input.Type == CloseWindow:
+			gameStruct.handleInput(input)
+		case gameStruct.paused:
+			continue
+		case isEdit(input.Type):
+			gameStruct.applyEdit(input)
+		default:
+			gameStruct.Step(input)
+			gameStruct.played = true
+		}
 
-		for _, monster := range gameStruct.CurrentLevel.Monsters {
-			monster.Update(gameStruct.CurrentLevel)
+		if gameStruct.Stats.Died {
+			if gameStruct.saves {
+				gameStruct.endRun("killed by " + gameStruct.Stats.KilledBy)
+			}
+			return
 		}
 
 		if len(gameStruct.LevelChans) == 0 {
 			return
 		}
 
-		for _, lchan := range gameStruct.LevelChans {
-			lchan <- gameStruct.CurrentLevel
+		gameStruct.publish()
+	}
+}
+
+// control handles the inputs from the menus, which never take a turn, and
+// reports whether input was one of them. Only a local game can be started
+// again or loaded. What happened last turn isn't published again after them.
+func (gameStruct *Game) control(input *Input) bool {
+	switch input.Type {
+	case Pause:
+		gameStruct.paused = true
+	case Resume:
+		gameStruct.paused = false
+	case NewRun, LoadGame:
+		if !gameStruct.Local {
+			break
+		}
+		gameStruct.begin()
+		if input.Type == LoadGame {
+			gameStruct.loadSave(slotFile(input.Slot))
+		}
+		gameStruct.lightLevels()
+		gameStruct.paused = false
+		gameStruct.played = true
+	case SaveGame:
+		err := gameStruct.save(slotFile(input.Slot))
+		if err != nil {
+			panic(err)
+		}
+		gameStruct.CurrentLevel.AddEvent("Game saved")
+	default:
+		return false
+	}
+	gameStruct.clearTurnEvents()
+	return true
+}
+
+// Step plays one turn: the input is applied and then the monsters on every
+// level with a player on it get to act.
+func (gameStruct *Game) Step(input *Input) {
+	gameStruct.clearTurnEvents()
+	if input.PlayerID == 0 && gameStruct.talk != nil {
+		gameStruct.answer(input)
+		return
+	}
+	if input.PlayerID == 0 && gameStruct.shopping != nil {
+		gameStruct.trade(input)
+		return
+	}
+	if input.PlayerID == 0 && input.Type == Travel && !gameStruct.travelTo(input.Pos) {
+		return
+	}
+	gameStruct.handleInput(input)
+	gameStruct.buryDead()
+	gameStruct.Turn++
+	gameStruct.Economy.restock(gameStruct.Turn)
+	gameStruct.rot()
+
+	for _, level := range gameStruct.activeLevels() {
+		players := gameStruct.playersOn(level)
+		for _, monster := range level.monstersByID() {
+			// One killed earlier in the turn lies dead until it is buried.
+			if monster.HP > 0 {
+				monster.Update(level, players)
+			}
+		}
+	}
+	gameStruct.buryDead()
+	gameStruct.spawnMonsters()
+	gameStruct.removeDeadRemotes()
+	gameStruct.updateLight()
+	if gameStruct.Local {
+		gameStruct.record()
+	}
+}
+
+// clearTurnEvents forgets what happened last turn before anything else does,
+// so that it isn't published again.
+func (gameStruct *Game) clearTurnEvents() {
+	for _, level := range gameStruct.Levels {
+		level.TurnEvents = level.TurnEvents[:0]
+	}
+}
+
+// publish hands every window its own snapshot of the current level, so the
+// UI goroutines never read the Level the game goroutine keeps mutating.
+func (gameStruct *Game) publish() {
+	shown := gameStruct.CurrentLevel
+	if gameStruct.editing != nil {
+		shown = gameStruct.editing
+	}
+	for _, lchan := range gameStruct.LevelChans {
+		snap := shown.Snapshot()
+		snap.Dialogue = gameStruct.dialogueView()
+		snap.Quests = gameStruct.Quests.View()
+		snap.Shop = gameStruct.shopView()
+		snap.Travel = gameStruct.travelGoal()
+		for _, remote := range gameStruct.RemotesOn(gameStruct.CurrentLevel) {
+			other := remote.Player.copy()
+			snap.Others[remote.Pos] = &other
 		}
+		lchan <- snap
 	}
 }
@@ -0,0 +1,302 @@
+package game
+
+import "testing"
+
+func TestMove(t *testing.T) {
+	g := newTestGame(t, "a", map[string]string{"a": `
+		#####
+		#@..#
+		#...#
+		#####
+	`})
+
+	g.play(Right)
+	g.wantPlayerAt("a", Pos{2, 1})
+	g.wantTurnEvent(Move, "Dralanor")
+	g.play(Down, Left)
+	g.wantPlayerAt("a", Pos{1, 2})
+	g.play(Up)
+	g.wantPlayerAt("a", Pos{1, 1})
+}
+
+func TestMoveIntoWall(t *testing.T) {
+	g := newTestGame(t, "a", map[string]string{"a": `
+		###
+		#@#
+		###
+	`})
+
+	g.play(Up, Down, Left, Right)
+	g.wantPlayerAt("a", Pos{1, 1})
+	if len(g.CurrentLevel.TurnEvents) != 0 {
+		t.Errorf("walking into a wall made events %+v", g.CurrentLevel.TurnEvents)
+	}
+}
+
+func TestOpenDoor(t *testing.T) {
+	g := newTestGame(t, "a", map[string]string{"a": `
+		#####
+		#@|.#
+		#####
+	`})
+	door := Pos{2, 1}
+
+	g.play(Right)
+	g.wantPlayerAt("a", Pos{1, 1})
+	g.wantTurnEvent(DoorOpen, "Dralanor")
+	if g.CurrentLevel.Map[door.Y][door.X].OverlayRune != OpenDoor {
+		t.Errorf("door is %q, want open", g.CurrentLevel.Map[door.Y][door.X].OverlayRune)
+	}
+
+	g.play(Right, Right)
+	g.wantPlayerAt("a", Pos{3, 1})
+}
+
+func TestClosedDoorBlocksSight(t *testing.T) {
+	g := newTestGame(t, "a", map[string]string{"a": `
+		#####
+		#@|.#
+		#####
+	`})
+	beyond := Pos{3, 1}
+
+	if g.CurrentLevel.Map[beyond.Y][beyond.X].Visible {
+		t.Errorf("%v is visible through a closed door", beyond)
+	}
+	g.play(Right)
+	if !g.CurrentLevel.Map[beyond.Y][beyond.X].Visible {
+		t.Errorf("%v isn't visible through the open door", beyond)
+	}
+}
+
+func TestPortal(t *testing.T) {
+	g := newTestGame(t, "a", map[string]string{
+		"a": `
+			#####
+			#@.d#
+			#####
+		`,
+		"b": `
+			#####
+			#u..#
+			#####
+		`,
+	})
+	g.link("a", Pos{3, 1}, "b", Pos{1, 1})
+	g.link("b", Pos{1, 1}, "a", Pos{3, 1})
+	g.player().HP = 13
+
+	g.play(Right, Right)
+	g.wantPlayerAt("b", Pos{1, 1})
+	g.wantEvent("Entered b")
+	g.wantTurnEvent(Portal, "Dralanor")
+	g.wantHP(&g.player().Character, 13)
+
+	g.play(Right, Left)
+	g.wantPlayerAt("a", Pos{3, 1})
+}
+
+func TestPortalRequiresItem(t *testing.T) {
+	g := newTestGame(t, "a", map[string]string{
+		"a": `
+			####
+			#@d#
+			####
+		`,
+		"b": `
+			###
+			#u#
+			###
+		`,
+	})
+	g.link("a", Pos{2, 1}, "b", Pos{1, 1})
+	g.Levels["a"].Portals[Pos{2, 1}].Requires = "Key"
+
+	g.play(Right)
+	g.wantPlayerAt("a", Pos{1, 1})
+	g.wantEvent("The way is barred without a Key")
+
+	g.player().give("Key")
+	g.play(Right)
+	g.wantPlayerAt("b", Pos{1, 1})
+}
+
+func TestMonsterApproachesAndAttacks(t *testing.T) {
+	g := newTestGame(t, "a", map[string]string{"a": `
+		#######
+		#@...R#
+		#######
+	`})
+	rat := g.CurrentLevel.Monsters[Pos{5, 1}]
+
+	// Rats move two tiles a turn.
+	g.play(Search)
+	if rat.Pos != (Pos{3, 1}) {
+		t.Fatalf("rat at %v, want 3,1", rat.Pos)
+	}
+	if g.CurrentLevel.Monsters[rat.Pos] != rat {
+		t.Errorf("rat isn't in Monsters at %v", rat.Pos)
+	}
+
+	g.play(Search)
+	if rat.Pos != (Pos{2, 1}) {
+		t.Errorf("rat at %v, want 2,1", rat.Pos)
+	}
+	g.wantTurnEvent(Attack, "Rat")
+	g.wantEvent("Rat attacked Dralanor for 0")
+	g.wantHP(&g.player().Character, 20)
+}
+
+func TestKillMonster(t *testing.T) {
+	g := newTestGame(t, "a", map[string]string{"a": `
+		####
+		#@S#
+		####
+	`})
+	spider := g.CurrentLevel.Monsters[Pos{2, 1}]
+
+	g.play(Right)
+	g.wantHP(&spider.Character, 80)
+	g.wantPlayerAt("a", Pos{1, 1})
+	hit := g.wantTurnEvent(Hit, "Dralanor")
+	if hit.Damage != 20 || hit.Target != "Spider" {
+		t.Errorf("hit %+v, want 20 damage to Spider", hit)
+	}
+
+	g.play(Right, Right, Right, Right)
+	g.wantEvent("Dralanor killed Spider")
+	g.wantTurnEvent(Death, "Dralanor")
+	if len(g.CurrentLevel.Monsters) != 0 {
+		t.Errorf("monsters left: %v", g.CurrentLevel.Monsters)
+	}
+
+	g.play(Right)
+	g.wantPlayerAt("a", Pos{2, 1})
+}
+
+func TestMonsterWaitsInTheDark(t *testing.T) {
+	g := newTestGame(t, "a", map[string]string{"a": `
+		###########
+		#@.......S#
+		###########
+	`})
+	level := g.CurrentLevel
+	level.Ambient = Light{}
+	g.player().Light = 0
+	g.updateLight()
+	spider := level.Monsters[Pos{9, 1}]
+
+	g.play(Search)
+	if spider.Pos != (Pos{9, 1}) {
+		t.Errorf("spider moved to %v towards a player it can't see", spider.Pos)
+	}
+
+	g.player().Light = 5
+	g.updateLight()
+	g.play(Search)
+	if spider.Pos == (Pos{9, 1}) {
+		t.Errorf("spider didn't move towards a player carrying a torch")
+	}
+}
+
+func TestCharacterIDsArePerGame(t *testing.T) {
+	maps := map[string]string{"a": `
+		######
+		#@.RS#
+		######
+	`}
+	for i := 0; i < 2; i++ {
+		g := newTestGame(t, "a", maps)
+		rat, spider := g.CurrentLevel.Monsters[Pos{3, 1}], g.CurrentLevel.Monsters[Pos{4, 1}]
+		remote := g.AddPlayer("Ana")
+		if rat.ID != 1 || spider.ID != 2 || remote.ID != 3 {
+			t.Errorf("game %d numbered rat %d, spider %d, remote %d, want 1, 2, 3", i, rat.ID, spider.ID, remote.ID)
+		}
+	}
+}
+
+func TestPauseStopsTurns(t *testing.T) {
+	g := newTestGame(t, "a", map[string]string{"a": `
+		######
+		#@...#
+		######
+	`})
+	levelChan, done := g.run()
+
+	g.InputChan <- &Input{Type: Pause}
+	<-levelChan
+	g.InputChan <- &Input{Type: Right}
+	g.InputChan <- &Input{Type: Resume}
+	snap := <-levelChan
+	if snap.Player.Pos != (Pos{1, 1}) || g.Turn != 0 {
+		t.Errorf("player at %v after %d turns, want no turns played while paused", snap.Player.Pos, g.Turn)
+	}
+
+	g.InputChan <- &Input{Type: Right}
+	snap = <-levelChan
+	if snap.Player.Pos != (Pos{2, 1}) {
+		t.Errorf("player at %v, want 2,1 once resumed", snap.Player.Pos)
+	}
+	g.InputChan <- &Input{Type: QuitGame}
+	<-done
+}
+
+func TestPauseForgetsLastTurn(t *testing.T) {
+	g := newTestGame(t, "a", map[string]string{"a": `
+		#####
+		#@S.#
+		#####
+	`})
+	levelChan, done := g.run()
+
+	g.InputChan <- &Input{Type: Right}
+	snap := <-levelChan
+	if len(snap.TurnEvents) == 0 {
+		t.Fatalf("no turn events for the attack")
+	}
+	for _, input := range []InputType{Pause, Resume} {
+		g.InputChan <- &Input{Type: input}
+		snap = <-levelChan
+		if len(snap.TurnEvents) != 0 {
+			t.Errorf("snapshot after %v has last turn's events %+v", input, snap.TurnEvents)
+		}
+	}
+	g.InputChan <- &Input{Type: QuitGame}
+	<-done
+}
+
+func TestSaveSlots(t *testing.T) {
+	g := newTestGame(t, "a", map[string]string{"a": `
+		######
+		#@...#
+		######
+	`})
+
+	g.play(Right)
+	g.control(&Input{Type: SaveGame, Slot: 2})
+	g.wantEvent("Game saved")
+	g.play(Right, Right)
+
+	infos := savedGames(g.dir)
+	if len(infos) != SaveSlots+1 {
+		t.Fatalf("%d save slots, want %d", len(infos), SaveSlots+1)
+	}
+	if infos[2].Level != "a" || infos[2].Turn != 1 {
+		t.Errorf("slot 2 holds %+v, want level a on turn 1", infos[2])
+	}
+	if infos[0].Level != "" || infos[1].Level != "" {
+		t.Errorf("slots 0 and 1 hold %+v and %+v, want them empty", infos[0], infos[1])
+	}
+
+	g.control(&Input{Type: LoadGame, Slot: 2})
+	g.wantPlayerAt("a", Pos{2, 1})
+	if g.Turn != 1 {
+		t.Errorf("turn %d after loading, want 1", g.Turn)
+	}
+
+	g.control(&Input{Type: NewRun})
+	g.wantPlayerAt("a", Pos{1, 1})
+	if g.Turn != 0 {
+		t.Errorf("turn %d in a new run, want 0", g.Turn)
+	}
+}
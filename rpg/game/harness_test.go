@@ -0,0 +1,145 @@
+package game
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testGame is a game played on levels drawn inline in a test, written to a
+// temporary directory and loaded from there by newGame, one turn at a time
+// and without any UI.
+type testGame struct {
+	*Game
+	t *testing.T
+}
+
+// newTestGame builds a game for one window with NewGameFromMaps in a
+// temporary directory.
+func newTestGame(t *testing.T, start string, maps map[string]string) *testGame {
+	t.Helper()
+	gameStruct, err := NewGameFromMaps(t.TempDir(), 1, 1, start, maps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testGame{Game: gameStruct, t: t}
+}
+
+// link adds a one-way portal, as a line of world.json would.
+func (g *testGame) link(from string, fromPos Pos, to string, toPos Pos) {
+	g.Levels[from].Portals[fromPos] = &LevelPos{Level: g.Levels[to], Pos: toPos}
+}
+
+// run plays the game in its own goroutine, the way it is played with a UI,
+// and reads its first snapshot. done is closed once Run returns.
+func (g *testGame) run() (levelChan chan *Snapshot, done chan bool) {
+	levelChan = make(chan *Snapshot)
+	g.LevelChans = []chan *Snapshot{levelChan}
+	g.InputChan = make(chan *Input)
+	done = make(chan bool)
+	go func() {
+		g.Run()
+		close(done)
+	}()
+	<-levelChan
+	return levelChan, done
+}
+
+// script loads src as the game's only content script.
+func (g *testGame) script(src string) {
+	g.t.Helper()
+	dir := filepath.Join(g.dir, scriptDir)
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		g.t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(dir, "test.star"), []byte(src), 0644)
+	if err != nil {
+		g.t.Fatal(err)
+	}
+	s, err := loadScripts(g.dir)
+	if err != nil {
+		g.t.Fatal(err)
+	}
+	s.players = g.playersOn
+	for _, level := range g.Levels {
+		level.scripts = s
+	}
+}
+
+// content writes v as the JSON file called name in the game's dir, such as
+// story.json or economy.json, and starts a new run to read it.
+func (g *testGame) content(name string, v interface{}) {
+	g.t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		g.t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(g.dir, name), data, 0644)
+	if err != nil {
+		g.t.Fatal(err)
+	}
+	g.control(&Input{Type: NewRun})
+}
+
+// play plays a turn for each input in turn, monsters included.
+func (g *testGame) play(inputs ...InputType) {
+	for _, input := range inputs {
+		g.Step(&Input{Type: input})
+	}
+}
+
+func (g *testGame) player() *Player {
+	return &g.CurrentLevel.Player
+}
+
+func (g *testGame) wantPlayerAt(level string, pos Pos) {
+	g.t.Helper()
+	if g.CurrentLevel.Name != level || g.player().Pos != pos {
+		g.t.Errorf("player at %s %v, want %s %v", g.CurrentLevel.Name, g.player().Pos, level, pos)
+	}
+}
+
+func (g *testGame) wantHP(c *Character, hp int) {
+	g.t.Helper()
+	if c.HP != hp {
+		g.t.Errorf("%s has %d HP, want %d", c.Name, c.HP, hp)
+	}
+}
+
+// events is the level's event log, oldest first.
+func (g *testGame) events() []string {
+	level := g.CurrentLevel
+	events := make([]string, 0, len(level.Events))
+	for i := range level.Events {
+		event := level.Events[(level.EventPos+i)%len(level.Events)]
+		if event != "" {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+func (g *testGame) wantEvent(event string) {
+	g.t.Helper()
+	for _, e := range g.events() {
+		if e == event {
+			return
+		}
+	}
+	g.t.Errorf("no event %q in %q", event, g.events())
+}
+
+// wantTurnEvent checks that the last turn included an event of the given
+// type by actor.
+func (g *testGame) wantTurnEvent(eventType GameEvent, actor string) TurnEvent {
+	g.t.Helper()
+	for _, e := range g.CurrentLevel.TurnEvents {
+		if e.Type == eventType && e.Actor == actor {
+			return e
+		}
+	}
+	g.t.Errorf("no turn event %v by %s in %+v", eventType, actor, g.CurrentLevel.TurnEvents)
+	return TurnEvent{}
+}
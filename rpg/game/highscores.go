@@ -0,0 +1,83 @@
+package game
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const highScoreFile = "highscores.json"
+
+// maxHighScores is how many runs the high score table keeps.
+const maxHighScores = 10
+
+// HighScore is one run that ended with the player dead.
+type HighScore struct {
+	Name    string    `json:"name"`
+	Score   int       `json:"score"`
+	Turns   int       `json:"turns"`
+	Deepest int       `json:"deepest"`
+	Kills   int       `json:"kills"`
+	Cause   string    `json:"cause"`
+	Date    time.Time `json:"date"`
+}
+
+// highScore scores the run: gold counts as it is, each kill for 10 and each
+// level of depth reached for 50.
+func (gameStruct *Game) highScore(cause string, date time.Time) HighScore {
+	p := gameStruct.CurrentLevel.Player
+	stats := gameStruct.Stats
+	score := p.Gold + 10*stats.KillCount() + 50*stats.Deepest
+	return HighScore{Name: p.Name, Score: score, Turns: gameStruct.Turn, Deepest: stats.Deepest, Kills: stats.KillCount(), Cause: cause, Date: date}
+}
+
+// HighScores reads the high score table, best first.
+func HighScores() ([]HighScore, error) {
+	return readHighScores(mapDir)
+}
+
+func readHighScores(dir string) ([]HighScore, error) {
+	data, err := os.ReadFile(filepath.Join(dir, highScoreFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var scores []HighScore
+	err = json.Unmarshal(data, &scores)
+	return scores, err
+}
+
+// addHighScore puts score in the table in dir, if it is good enough, and
+// writes the table back out.
+func addHighScore(dir string, score HighScore) error {
+	scores, err := readHighScores(dir)
+	if err != nil {
+		return err
+	}
+	scores = rankHighScores(append(scores, score))
+
+	data, err := json.MarshalIndent(scores, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, highScoreFile), append(data, '\n'), 0644)
+}
+
+// rankHighScores sorts scores best first, earlier runs ahead of later ones
+// with the same score, and drops all but the best maxHighScores.
+func rankHighScores(scores []HighScore) []HighScore {
+	sort.SliceStable(scores, func(i, j int) bool {
+		if scores[i].Score != scores[j].Score {
+			return scores[i].Score > scores[j].Score
+		}
+		return scores[i].Date.Before(scores[j].Date)
+	})
+	if len(scores) > maxHighScores {
+		scores = scores[:maxHighScores]
+	}
+	return scores
+}
@@ -0,0 +1,76 @@
+package game
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// NewGameFromMaps starts a game for numWindows windows on levels drawn in
+// the .map format, keyed by level name, starting on start. Each level is
+// titled with its name. The maps and a world.json are written to dir, which
+// the game is then read from like mapDir, and it always plays out the same
+// way from the same seed and the same inputs. It is what tests play on.
+//
+// Leading and trailing blank lines and the indentation common to every line
+// are removed, so maps can be written as indented raw strings.
+func NewGameFromMaps(dir string, numWindows int, seed int64, start string, maps map[string]string) (*Game, error) {
+	if maps[start] == "" {
+		return nil, fmt.Errorf("no start level %s", start)
+	}
+	world := &World{Start: start}
+	for name, m := range maps {
+		lines := mapLines(m)
+		_, invalid := parseLevel(name, lines, newPlayer(), &characterIDs{})
+		if len(invalid) > 0 {
+			return nil, fmt.Errorf("level %s: invalid characters at %v", name, invalid)
+		}
+		err := os.WriteFile(filepath.Join(dir, name+".map"), []byte(strings.Join(lines, "\n")+"\n"), 0644)
+		if err != nil {
+			return nil, err
+		}
+		world.Levels = append(world.Levels, LevelInfo{Name: name, Title: name})
+	}
+	sort.Slice(world.Levels, func(i, j int) bool { return world.Levels[i].Name < world.Levels[j].Name })
+	file, err := os.Create(filepath.Join(dir, worldFile))
+	if err != nil {
+		return nil, err
+	}
+	err = world.Write(file)
+	file.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	gameStruct := newGame(numWindows, seed, dir)
+	gameStruct.lightLevels()
+	return gameStruct, nil
+}
+
+func mapLines(m string) []string {
+	lines := strings.Split(m, "\n")
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	indent := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		n := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	for i, line := range lines {
+		if len(line) >= indent && indent > 0 {
+			lines[i] = line[indent:]
+		}
+	}
+	return lines
+}
@@ -0,0 +1,36 @@
+package game
+
+type Item struct {
+	Entity
+}
+
+func (p *Player) hasItem(name string) bool {
+	for _, item := range p.Items {
+		if item.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Player) give(name string) {
+	item := &Item{}
+	item.Name = name
+	p.Items = append(p.Items, item)
+}
+
+// take removes one item called name.
+func (p *Player) take(name string) {
+	for i, item := range p.Items {
+		if item.Name == name {
+			p.Items = append(p.Items[:i], p.Items[i+1:]...)
+			return
+		}
+	}
+}
+
+// canUse reports whether p meets a portal's condition. The only condition so
+// far is carrying an item of the given name.
+func (p *Player) canUse(portal *LevelPos) bool {
+	return portal.Requires == "" || p.hasItem(portal.Requires)
+}
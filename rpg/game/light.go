@@ -0,0 +1,151 @@
+package game
+
+import "math"
+
+// Light is a colour of light, or how much of each colour lights a tile.
+type Light struct {
+	R uint8 `json:"r"`
+	G uint8 `json:"g"`
+	B uint8 `json:"b"`
+}
+
+// Brightness is the strongest of the light's colours.
+func (l Light) Brightness() uint8 {
+	b := l.R
+	if l.G > b {
+		b = l.G
+	}
+	if l.B > b {
+		b = l.B
+	}
+	return b
+}
+
+func (l Light) add(other Light, f float64) Light {
+	channel := func(a, b uint8) uint8 {
+		v := float64(a) + float64(b)*f
+		if v > 255 {
+			return 255
+		}
+		return uint8(v)
+	}
+	return Light{R: channel(l.R, other.R), G: channel(l.G, other.G), B: channel(l.B, other.B)}
+}
+
+// Daylight is the ambient light of levels that don't set their own, bright
+// enough to see everything.
+var Daylight = Light{R: 255, G: 255, B: 255}
+
+// torchLight is the colour of the torch every player carries.
+var torchLight = Light{R: 255, G: 200, B: 140}
+
+// seeLight is how bright a tile has to be for anyone without dark vision to
+// make it out.
+const seeLight = 40
+
+// LightSource is a fixed light, such as a brazier on the wall.
+type LightSource struct {
+	X      int   `json:"x"`
+	Y      int   `json:"y"`
+	Radius int   `json:"radius"`
+	Color  Light `json:"color"`
+}
+
+// computeLight works out how much light falls on every tile of the level
+// from its ambient light, its light sources, glowing monsters and the lights
+// the given players carry. Walls cast shadows.
+func (level *Level) computeLight(players []*Character) {
+	for y, row := range level.Map {
+		for x := range row {
+			level.Map[y][x].Light = level.Ambient
+		}
+	}
+	for _, source := range level.Lights {
+		level.shine(Pos{X: source.X, Y: source.Y}, source.Radius, source.Color)
+	}
+	for _, monster := range level.Monsters {
+		level.shine(monster.Pos, monster.Light, monster.LightColor)
+	}
+	for _, p := range players {
+		level.shine(p.Pos, p.Light, p.LightColor)
+	}
+}
+
+// shine lights the tiles within radius of pos that the light reaches, less
+// the further they are from it.
+func (level *Level) shine(pos Pos, radius int, color Light) {
+	if radius <= 0 || !inRange(level, pos) {
+		return
+	}
+	lit := make(map[Pos]bool)
+	level.fieldOfView(pos, radius, func(p Pos) {
+		lit[p] = true
+	})
+	for p := range lit {
+		f := 1 - distance(pos, p)/float64(radius+1)
+		if f <= 0 {
+			continue
+		}
+		tile := &level.Map[p.Y][p.X]
+		tile.Light = tile.Light.add(color, f)
+	}
+}
+
+// canSee reports whether viewer can make out the tile at pos, which has to be
+// lit well enough or close enough for their dark vision. It doesn't check
+// that nothing is in the way.
+func (level *Level) canSee(viewer *Character, pos Pos) bool {
+	if distance(viewer.Pos, pos) > float64(viewer.SightRange) {
+		return false
+	}
+	if distance(viewer.Pos, pos) <= float64(viewer.DarkVision) {
+		return true
+	}
+	return level.Map[pos.Y][pos.X].Light.Brightness() >= seeLight
+}
+
+func distance(a, b Pos) float64 {
+	dx := float64(a.X - b.X)
+	dy := float64(a.Y - b.Y)
+	return math.Sqrt(dx*dx + dy*dy)
+}
+
+// refreshView works out again which tiles the local player can see.
+func (level *Level) refreshView() {
+	for y, row := range level.Map {
+		for x := range row {
+			level.Map[y][x].Visible = false
+		}
+	}
+	level.lineOfSight()
+}
+
+// clearLine reports whether nothing blocks the view from a to b.
+func (level *Level) clearLine(a, b Pos) bool {
+	blocked := false
+	level.bresenham(a, b, func(pos Pos) {
+		if pos != a && pos != b && !canSeeThrough(level, pos) {
+			blocked = true
+		}
+	})
+	return !blocked
+}
+
+// lightLevels lights every level for the start of the game.
+func (gameStruct *Game) lightLevels() {
+	for _, level := range gameStruct.Levels {
+		level.computeLight(gameStruct.playersOn(level))
+	}
+	gameStruct.CurrentLevel.refreshView()
+}
+
+// updateLight relights every level someone is playing on and works out again
+// what the local player sees.
+func (gameStruct *Game) updateLight() {
+	for _, level := range gameStruct.activeLevels() {
+		level.computeLight(gameStruct.playersOn(level))
+	}
+	if gameStruct.CurrentLevel != nil {
+		gameStruct.CurrentLevel.refreshView()
+	}
+}
@@ -1,24 +1,62 @@
 package game
 
+import (
+	"math"
+	"sort"
+)
+
+// Monster is a character the game plays. Its ID also tells it apart between
+// snapshots, so that the UI can follow it as it moves.
 type Monster struct {
 	Character
+	// spawner is one more than the index in Level.Spawners of the spawner
+	// that made the monster, or 0 if none did.
+	spawner int
+}
+
+// characterIDs numbers the monsters and remote players of a game in the
+// order they turn up, so a game played the same way numbers them the same.
+type characterIDs struct {
+	last int
+}
+
+func (ids *characterIDs) next() int {
+	ids.last++
+	return ids.last
+}
+
+// monsterTypes maps monster names, as used in spawn tables, to constructors.
+var monsterTypes = map[string]func(Pos) *Monster{
+	"Rat":    NewRat,
+	"Spider": NewSpider,
 }
 
 func NewRat(pos Pos) *Monster {
-	return &Monster{Character: Character{Entity: Entity{Pos: pos, Name: "Rat", Rune: 'R'}, HP: 200, Strength: 0, Speed: 2.0, AP: 0.0, SightRange: 10}}
+	return &Monster{Character: Character{Entity: Entity{Pos: pos, Name: "Rat", Rune: 'R'}, HP: 200, Strength: 0, Speed: 2.0, AP: 0.0, SightRange: 10, DarkVision: 8}}
 }
 
+// Spiders glow faintly green, and see in the dark.
 func NewSpider(pos Pos) *Monster {
-	return &Monster{Character: Character{Entity: Entity{Pos: pos, Name: "Spider", Rune: 'S'}, HP: 100, Strength: 0, Speed: 1.0, AP: 0.0, SightRange: 10}}
+	return &Monster{Character: Character{Entity: Entity{Pos: pos, Name: "Spider", Rune: 'S'}, HP: 100, Strength: 0, Speed: 1.0, AP: 0.0, SightRange: 10, Light: 2, LightColor: Light{R: 60, G: 200, B: 80}, DarkVision: 6}}
 }
 
-func (m *Monster) Update(level *Level) {
+func (m *Monster) Update(level *Level, players []*Character) {
 	m.AP += m.Speed
-	playerPos := level.Player.Pos
+	if level.scripts.monsterTurn(level, m, players) {
+		// Whole action points the script didn't use are lost, so that a
+		// monster that waits doesn't save up a burst of moves.
+		m.AP -= math.Floor(m.AP)
+		return
+	}
+	target := m.nearest(level, players)
+	if target == nil {
+		m.Pass()
+		return
+	}
 
 	apInt := int(m.AP)
 
-	positions := level.astar(m.Pos, playerPos)
+	positions := level.astar(m.Pos, target.Pos)
 
 	if len(positions) == 0 {
 		m.Pass()
@@ -26,36 +64,63 @@ func (m *Monster) Update(level *Level) {
 	}
 
 	moveIndex := 1
-	for i := 0; i < apInt; i++ {
+	for i := 0; i < apInt && m.HP > 0; i++ {
 		if moveIndex < len(positions) {
-			m.Move(positions[moveIndex], level)
+			m.Move(positions[moveIndex], level, players)
 			moveIndex++
 			m.AP--
 		}
 	}
 }
 
+// nearest is the closest player the monster can see, or nil if it can't see
+// any.
+func (m *Monster) nearest(level *Level, players []*Character) *Character {
+	var target *Character
+	bestDist := 0
+	for _, p := range players {
+		if !level.canSee(&m.Character, p.Pos) || !level.clearLine(m.Pos, p.Pos) {
+			continue
+		}
+		dist := int(math.Abs(float64(p.X-m.X))) + int(math.Abs(float64(p.Y-m.Y)))
+		if target == nil || dist < bestDist {
+			target = p
+			bestDist = dist
+		}
+	}
+	return target
+}
+
+// monstersByID lists the level's monsters in the order they were made, which
+// is the order they take their turns in, so that a game plays out the same
+// way every time.
+func (level *Level) monstersByID() []*Monster {
+	monsters := make([]*Monster, 0, len(level.Monsters))
+	for _, monster := range level.Monsters {
+		monsters = append(monsters, monster)
+	}
+	sort.Slice(monsters, func(i, j int) bool { return monsters[i].ID < monsters[j].ID })
+	return monsters
+}
+
 func (m *Monster) Pass() {
 	m.AP -= m.Speed
 }
 
-func (m *Monster) Move(to Pos, level *Level) {
+func (m *Monster) Move(to Pos, level *Level, players []*Character) {
+	for _, p := range players {
+		if p.Pos == to {
+			level.Attack(&m.Character, p)
+			return
+		}
+	}
+
 	_, exists := level.Monsters[to]
-	if !exists && to != level.Player.Pos {
+	if !exists {
 		delete(level.Monsters, m.Pos)
 		level.Monsters[to] = m
 		m.Pos = to
-		return
+		level.emit(TurnEvent{Type: Move, Actor: m.Name, ActorID: m.ID, TargetID: Nobody, Pos: to})
+		level.scripts.enteredTile(level, &m.Character)
 	}
-	if to == level.Player.Pos {
-		level.Attack(&m.Character, &level.Player.Character)
-
-		if m.HP <= 0 {
-			delete(level.Monsters, m.Pos)
-		}
-		if level.Player.HP <= 0 {
-			panic("DEAD")
-		}
-	}
-
 }
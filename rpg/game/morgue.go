@@ -0,0 +1,112 @@
+package game
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// morgueDir is where a morgue file is written for every run that ends, in
+// the game's dir.
+const morgueDir = "morgue"
+
+// writeMorgue writes the morgue file for the run, named after the player and
+// when it ended.
+func (gameStruct *Game) writeMorgue(cause string, date time.Time) error {
+	dir := filepath.Join(gameStruct.dir, morgueDir)
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+	name := gameStruct.CurrentLevel.Player.Name + "-" + date.Format("20060102-150405") + ".txt"
+	return os.WriteFile(filepath.Join(dir, name), []byte(gameStruct.morgue(cause, date)), 0644)
+}
+
+// morgue is a plain text account of the run: the character, what they did,
+// the level they ended on as far as they had seen it, and its last events.
+func (gameStruct *Game) morgue(cause string, date time.Time) string {
+	level := gameStruct.CurrentLevel
+	p := level.Player
+	stats := gameStruct.Stats
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s, %s on %s after %d turns.\n", p.Name, cause, level.Title, gameStruct.Turn)
+	fmt.Fprintf(&b, "%s\n\n", date.Format("2006-01-02 15:04:05"))
+
+	fmt.Fprintf(&b, "HP %d  Strength %d  Gold %d\n", p.HP, p.Strength, p.Gold)
+	items := make([]string, 0, len(p.Items))
+	for _, item := range p.Items {
+		items = append(items, item.Name)
+	}
+	if len(items) == 0 {
+		items = append(items, "nothing")
+	}
+	fmt.Fprintf(&b, "Carrying %s\n\n", strings.Join(items, ", "))
+
+	fmt.Fprintf(&b, "Deepest level %d\n", stats.Deepest)
+	fmt.Fprintf(&b, "Tiles explored %d\n", stats.Explored)
+	fmt.Fprintf(&b, "Damage dealt %d, taken %d\n", stats.DamageDealt, stats.DamageTaken)
+	monsters := make([]string, 0, len(stats.Kills))
+	for monster := range stats.Kills {
+		monsters = append(monsters, monster)
+	}
+	sort.Strings(monsters)
+	fmt.Fprintf(&b, "Killed %d\n", stats.KillCount())
+	for _, monster := range monsters {
+		fmt.Fprintf(&b, "  %3d %s\n", stats.Kills[monster], monster)
+	}
+	b.WriteString("\n")
+
+	for _, line := range level.seenMap() {
+		b.WriteString(strings.TrimRight(line, " "))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	for i := range level.Events {
+		event := level.Events[(level.EventPos+i)%len(level.Events)]
+		if event != "" {
+			b.WriteString(event + "\n")
+		}
+	}
+	return b.String()
+}
+
+// seenMap draws the level the way the player last saw it, with the player as
+// @ and the monsters, NPCs, loot ($) and corpses (%) still in sight.
+func (level *Level) seenMap() []string {
+	lines := make([]string, len(level.Map))
+	for y, row := range level.Map {
+		line := make([]rune, len(row))
+		for x, tile := range row {
+			pos := Pos{x, y}
+			monster, isMonster := level.Monsters[pos]
+			npc, isNPC := level.NPCs[pos]
+			_, isCorpse := level.Corpses[pos]
+			_, isLoot := level.Loot[pos]
+			switch {
+			case pos == level.Player.Pos:
+				line[x] = '@'
+			case !tile.Seen || tile.Rune == Blank:
+				line[x] = ' '
+			case tile.Visible && isMonster:
+				line[x] = monster.Rune
+			case tile.Visible && isNPC:
+				line[x] = npc.Rune
+			case tile.Visible && isLoot:
+				line[x] = '$'
+			case tile.Visible && isCorpse:
+				line[x] = '%'
+			case tile.OverlayRune != Blank:
+				line[x] = tile.OverlayRune
+			default:
+				line[x] = tile.Rune
+			}
+		}
+		lines[y] = string(line)
+	}
+	return lines
+}
@@ -0,0 +1,85 @@
+package game
+
+import "testing"
+
+func TestAstar(t *testing.T) {
+	g := newTestGame(t, "a", map[string]string{"a": `
+		#######
+		#@....#
+		####..#
+		#.....#
+		#######
+	`})
+	level := g.CurrentLevel
+	start, goal := Pos{1, 1}, Pos{1, 3}
+
+	path := level.astar(start, goal)
+	// Round the end of the wall: 3 steps right, 2 down and 3 back left.
+	if len(path) != 9 {
+		t.Fatalf("path %v has %d tiles, want 9", path, len(path))
+	}
+	if path[0] != start || path[len(path)-1] != goal {
+		t.Errorf("path %v doesn't run from %v to %v", path, start, goal)
+	}
+	for i := 1; i < len(path); i++ {
+		d := path[i].X - path[i-1].X + path[i].Y - path[i-1].Y
+		if (d != 1 && d != -1) || !canWalk(level, path[i]) {
+			t.Errorf("step %d from %v to %v isn't a walkable neighbour", i, path[i-1], path[i])
+		}
+	}
+}
+
+func TestAstarAroundMonster(t *testing.T) {
+	g := newTestGame(t, "a", map[string]string{"a": `
+		#####
+		#...#
+		#@R.#
+		#####
+	`})
+
+	path := g.CurrentLevel.astar(Pos{1, 2}, Pos{3, 2})
+	if len(path) != 5 {
+		t.Errorf("path %v, want the 5 tiles over the rat", path)
+	}
+}
+
+func TestAstarUnreachable(t *testing.T) {
+	g := newTestGame(t, "a", map[string]string{"a": `
+		#####
+		#@#.#
+		#####
+	`})
+
+	path := g.CurrentLevel.astar(Pos{1, 1}, Pos{3, 1})
+	if path != nil {
+		t.Errorf("found path %v through a wall", path)
+	}
+}
+
+func TestBfsFloor(t *testing.T) {
+	g := newTestGame(t, "a", map[string]string{"a": `
+		######
+		#@R|d#
+		######
+	`})
+
+	for x := 1; x <= 4; x++ {
+		tile := g.CurrentLevel.Map[1][x]
+		if tile.Rune != DirtFloor {
+			t.Errorf("tile %d,1 has floor %q, want %q", x, tile.Rune, DirtFloor)
+		}
+	}
+	if g.CurrentLevel.Map[1][3].OverlayRune != ClosedDoor || g.CurrentLevel.Map[1][4].OverlayRune != DownStair {
+		t.Errorf("overlays lost: %+v", g.CurrentLevel.Map[1])
+	}
+}
+
+func TestMapLines(t *testing.T) {
+	lines := mapLines(`
+		##
+		 #
+	`)
+	if len(lines) != 2 || lines[0] != "##" || lines[1] != " #" {
+		t.Errorf("mapLines gave %q", lines)
+	}
+}
@@ -0,0 +1,203 @@
+package game
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Quest is handed out and finished by dialogue actions. It is complete once
+// every objective is met, and done once its reward has been given.
+type Quest struct {
+	Name       string      `json:"name"`
+	Title      string      `json:"title"`
+	Objectives []Objective `json:"objectives"`
+	Reward     Reward      `json:"reward,omitempty"`
+}
+
+// Objective is either killing Count monsters called Kill or visiting the level
+// called Visit.
+type Objective struct {
+	Kill  string `json:"kill,omitempty"`
+	Count int    `json:"count,omitempty"`
+	Visit string `json:"visit,omitempty"`
+}
+
+func (o Objective) goal() int {
+	if o.Kill != "" && o.Count > 1 {
+		return o.Count
+	}
+	return 1
+}
+
+type Reward struct {
+	HP       int    `json:"hp,omitempty"`
+	Strength int    `json:"strength,omitempty"`
+	Item     string `json:"item,omitempty"`
+}
+
+type QuestStatus int
+
+const (
+	QuestUnstarted QuestStatus = iota
+	QuestActive
+	QuestComplete
+	QuestDone
+)
+
+var questStatusNames = []string{"unstarted", "active", "complete", "done"}
+
+func (s QuestStatus) String() string {
+	return questStatusNames[s]
+}
+
+func (s QuestStatus) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *QuestStatus) UnmarshalText(text []byte) error {
+	for i, name := range questStatusNames {
+		if name == string(text) {
+			*s = QuestStatus(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown quest status %q", text)
+}
+
+// QuestState is how far the player has got with a quest. Progress counts
+// towards each objective in turn.
+type QuestState struct {
+	Status   QuestStatus `json:"status"`
+	Progress []int       `json:"progress,omitempty"`
+}
+
+// QuestLog is the local player's quests.
+type QuestLog struct {
+	Quests []*Quest
+	States map[string]*QuestState
+}
+
+func newQuestLog(quests []*Quest) *QuestLog {
+	return &QuestLog{Quests: quests, States: make(map[string]*QuestState)}
+}
+
+func (log *QuestLog) quest(name string) *Quest {
+	for _, q := range log.Quests {
+		if q.Name == name {
+			return q
+		}
+	}
+	return nil
+}
+
+func (log *QuestLog) status(name string) QuestStatus {
+	state, exists := log.States[name]
+	if !exists {
+		return QuestUnstarted
+	}
+	return state.Status
+}
+
+// start makes an unstarted quest active.
+func (log *QuestLog) start(name string, level *Level) {
+	q := log.quest(name)
+	if q == nil || log.status(name) != QuestUnstarted {
+		return
+	}
+	log.States[name] = &QuestState{Status: QuestActive, Progress: make([]int, len(q.Objectives))}
+	level.AddEvent("New quest: " + q.Title)
+	log.checkComplete(q, level)
+}
+
+// finish gives the reward for a complete quest to player.
+func (log *QuestLog) finish(name string, player *Player, level *Level) {
+	q := log.quest(name)
+	if q == nil || log.status(name) != QuestComplete {
+		return
+	}
+	log.States[name].Status = QuestDone
+	player.HP += q.Reward.HP
+	player.Strength += q.Reward.Strength
+	if q.Reward.Item != "" {
+		player.give(q.Reward.Item)
+		level.AddEvent("Received " + q.Reward.Item)
+	}
+	level.AddEvent("Quest done: " + q.Title)
+}
+
+// progress counts one more towards every active objective match accepts.
+func (log *QuestLog) progress(level *Level, match func(Objective) bool) {
+	if log == nil {
+		return
+	}
+	for _, q := range log.Quests {
+		state, exists := log.States[q.Name]
+		if !exists || state.Status != QuestActive {
+			continue
+		}
+		for i, o := range q.Objectives {
+			if match(o) && state.Progress[i] < o.goal() {
+				state.Progress[i]++
+			}
+		}
+		log.checkComplete(q, level)
+	}
+}
+
+func (log *QuestLog) killed(victim string, level *Level) {
+	log.progress(level, func(o Objective) bool { return o.Kill == victim })
+}
+
+func (log *QuestLog) visited(levelName string, level *Level) {
+	log.progress(level, func(o Objective) bool { return o.Visit == levelName })
+}
+
+func (log *QuestLog) checkComplete(q *Quest, level *Level) {
+	state := log.States[q.Name]
+	if state.Status != QuestActive {
+		return
+	}
+	for i, o := range q.Objectives {
+		if state.Progress[i] < o.goal() {
+			return
+		}
+	}
+	state.Status = QuestComplete
+	level.AddEvent("Quest complete: " + q.Title)
+}
+
+// QuestView is a started quest as the UI shows it.
+type QuestView struct {
+	Title      string
+	Status     QuestStatus
+	Objectives []string
+}
+
+// View lists the started quests, in the order the story gives them.
+func (log *QuestLog) View() []QuestView {
+	if log == nil {
+		return nil
+	}
+	views := make([]QuestView, 0)
+	for _, q := range log.Quests {
+		state, exists := log.States[q.Name]
+		if !exists {
+			continue
+		}
+		view := QuestView{Title: q.Title, Status: state.Status}
+		for i, o := range q.Objectives {
+			var text string
+			if o.Kill != "" {
+				text = "Kill " + o.Kill + " " + strconv.Itoa(state.Progress[i]) + "/" + strconv.Itoa(o.goal())
+			} else {
+				text = "Visit " + o.Visit
+				if state.Progress[i] > 0 {
+					text += " (done)"
+				}
+			}
+			view.Objectives = append(view.Objectives, text)
+		}
+		views = append(views, view)
+	}
+	return views
+}
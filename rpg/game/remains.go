@@ -0,0 +1,96 @@
+package game
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// corpseTurns is how long a corpse lies about before it rots away.
+const corpseTurns = 60
+
+// Corpse is what is left of a monster where it was killed. Monster and Rune
+// are the monster's, so the UI can draw it the way it looked. It rots away
+// once turn Rots is over.
+type Corpse struct {
+	Monster string
+	Rune    rune
+	Rots    int
+}
+
+// Loot is what lies on a tile for a player to pick up by walking onto it.
+type Loot struct {
+	Gold  int
+	Items []string
+}
+
+// kill takes a dead monster off the level, leaving its corpse and
+// whatever it drops in its place.
+func (gameStruct *Game) kill(monster *Monster, level *Level) {
+	delete(level.Monsters, monster.Pos)
+	level.Corpses[monster.Pos] = &Corpse{Monster: monster.Name, Rune: monster.Rune, Rots: gameStruct.Turn + corpseTurns}
+	gameStruct.drop(monster, level)
+}
+
+// buryDead leaves the corpses and drops of the monsters killed on every level
+// since it was last called. The levels go in name order, so that what is
+// dropped doesn't depend on map order.
+func (gameStruct *Game) buryDead() {
+	names := make([]string, 0, len(gameStruct.Levels))
+	for name := range gameStruct.Levels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		level := gameStruct.Levels[name]
+		for _, monster := range level.monstersByID() {
+			if monster.HP <= 0 {
+				gameStruct.kill(monster, level)
+			}
+		}
+	}
+}
+
+// rot takes away the corpses that have lain long enough on every level.
+func (gameStruct *Game) rot() {
+	for _, level := range gameStruct.Levels {
+		for pos, corpse := range level.Corpses {
+			if gameStruct.Turn > corpse.Rots {
+				delete(level.Corpses, pos)
+			}
+		}
+	}
+}
+
+// dropLoot adds gold and items to whatever already lies at pos.
+func (level *Level) dropLoot(pos Pos, gold int, items []string) {
+	if gold <= 0 && len(items) == 0 {
+		return
+	}
+	loot := level.Loot[pos]
+	if loot == nil {
+		loot = &Loot{}
+		level.Loot[pos] = loot
+	}
+	loot.Gold += gold
+	loot.Items = append(loot.Items, items...)
+}
+
+// pickUp gives the player everything lying where they stand.
+func (gameStruct *Game) pickUp(p *Player, level *Level) {
+	loot := level.Loot[p.Pos]
+	if loot == nil {
+		return
+	}
+	delete(level.Loot, p.Pos)
+	found := make([]string, 0, len(loot.Items)+1)
+	if loot.Gold > 0 {
+		p.Gold += loot.Gold
+		found = append(found, strconv.Itoa(loot.Gold)+" gold")
+	}
+	for _, item := range loot.Items {
+		gameStruct.receive(p, item)
+		found = append(found, item)
+	}
+	level.AddEvent(p.Name + " found " + strings.Join(found, ", "))
+}
@@ -0,0 +1,60 @@
+package game
+
+import "testing"
+
+func TestKillLeavesRemains(t *testing.T) {
+	g := newTestGame(t, "a", map[string]string{"a": `
+		#####
+		#@S.#
+		#####
+	`})
+	g.Economy.Items = []ItemInfo{{Name: "Healing Draught", Price: 8, HP: 10}}
+	g.Economy.Drops = []Drop{{Monster: "Spider", Min: 5, Max: 5, Items: []ItemDrop{{Item: "Healing Draught", Chance: 100}, {Item: "Silver Ring", Chance: 100}}}}
+
+	g.play(Right, Right, Right, Right, Right)
+	corpse := g.CurrentLevel.Corpses[Pos{2, 1}]
+	if corpse == nil || corpse.Monster != "Spider" || corpse.Rune != 'S' {
+		t.Fatalf("corpse %+v, want the spider's", corpse)
+	}
+	loot := g.CurrentLevel.Loot[Pos{2, 1}]
+	if loot == nil || loot.Gold != 5 || len(loot.Items) != 2 {
+		t.Fatalf("loot %+v, want 5 gold and two items", loot)
+	}
+	snap := g.CurrentLevel.Snapshot()
+	if snap.Corpses[Pos{2, 1}] == nil || snap.Loot[Pos{2, 1}] == nil {
+		t.Errorf("snapshot is missing the remains")
+	}
+
+	g.play(Right)
+	g.wantPlayerAt("a", Pos{2, 1})
+	g.wantEvent("Dralanor found 5 gold, Healing Draught, Silver Ring")
+	if g.player().Gold != 5 || !g.player().hasItem("Silver Ring") || g.player().hasItem("Healing Draught") {
+		t.Errorf("player has %d gold and %v, want 5 gold and only the ring", g.player().Gold, g.player().Items)
+	}
+	g.wantHP(&g.player().Character, 30)
+	if len(g.CurrentLevel.Loot) != 0 {
+		t.Errorf("loot left behind: %v", g.CurrentLevel.Loot)
+	}
+}
+
+func TestCorpsesRot(t *testing.T) {
+	g := newTestGame(t, "a", map[string]string{"a": `
+		####
+		#@S#
+		####
+	`})
+	g.play(Right, Right, Right, Right, Right)
+	if len(g.CurrentLevel.Corpses) != 1 {
+		t.Fatalf("corpses %v, want the spider's", g.CurrentLevel.Corpses)
+	}
+	for i := 1; i < corpseTurns; i++ {
+		g.play(Search)
+	}
+	if len(g.CurrentLevel.Corpses) != 1 {
+		t.Errorf("corpse rotted before %d turns", corpseTurns)
+	}
+	g.play(Search)
+	if len(g.CurrentLevel.Corpses) != 0 {
+		t.Errorf("corpse still there after %d turns", corpseTurns)
+	}
+}
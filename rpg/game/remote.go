@@ -0,0 +1,182 @@
+package game
+
+// RemotePlayer is a player joined from another process. Unlike Level.Player,
+// which every level carries its own copy of, a remote player lives on exactly
+// one level at a time and is removed from the game when it dies. Its ID is
+// the PlayerID of its inputs.
+type RemotePlayer struct {
+	Player
+	Level *Level
+}
+
+// AddPlayer puts a new remote player on the free tile closest to where the
+// local player starts and returns it. Remote IDs start at 1 so that the zero
+// PlayerID in an Input keeps meaning the local player.
+func (gameStruct *Game) AddPlayer(name string) *RemotePlayer {
+	gameStruct.clearTurnEvents()
+	level := gameStruct.CurrentLevel
+
+	remote := &RemotePlayer{Level: level}
+	remote.ID = gameStruct.ids.next()
+	remote.Name = name
+	remote.Rune = '@'
+	remote.HP = 20
+	remote.Strength = 20
+	remote.Speed = 1
+	remote.AP = 0
+	remote.SightRange = 7
+	remote.Light = 5
+	remote.LightColor = torchLight
+	remote.DarkVision = 1
+	remote.Pos = gameStruct.freeTileNear(level, level.Player.Pos)
+
+	gameStruct.Remotes[remote.ID] = remote
+	level.AddEvent(name + " joined")
+	gameStruct.updateLight()
+	return remote
+}
+
+func (gameStruct *Game) RemovePlayer(id int) {
+	remote, exists := gameStruct.Remotes[id]
+	if !exists {
+		return
+	}
+	gameStruct.clearTurnEvents()
+	delete(gameStruct.Remotes, id)
+	remote.Level.AddEvent(remote.Name + " left")
+	gameStruct.updateLight()
+}
+
+func (gameStruct *Game) handleRemoteInput(input *Input) {
+	remote, exists := gameStruct.Remotes[input.PlayerID]
+	if !exists {
+		return
+	}
+	newPos := remote.Pos
+	switch input.Type {
+	case Up:
+		newPos.Y--
+	case Down:
+		newPos.Y++
+	case Left:
+		newPos.X--
+	case Right:
+		newPos.X++
+	case Search:
+		remote.Level.scripts.interacted(remote.Level, &remote.Character, remote.Pos)
+		return
+	default:
+		return
+	}
+
+	level := remote.Level
+	monster, exists := level.Monsters[newPos]
+	switch {
+	case exists:
+		level.Attack(&remote.Character, &monster.Character)
+	case gameStruct.playerAt(level, newPos) != nil:
+	case canWalk(level, newPos):
+		levelAndPos := level.Portals[newPos]
+		if levelAndPos != nil && !remote.canUse(levelAndPos) {
+			level.AddEvent(remote.Name + " is barred without a " + levelAndPos.Requires)
+		} else if levelAndPos != nil {
+			remote.Level = levelAndPos.Level
+			remote.Pos = gameStruct.freeTileNear(levelAndPos.Level, levelAndPos.Pos)
+			remote.Level.emit(TurnEvent{Type: Portal, Actor: remote.Name, ActorID: remote.ID, TargetID: Nobody, Pos: remote.Pos})
+			remote.Level.scripts.enteredTile(remote.Level, &remote.Character)
+		} else {
+			remote.Pos = newPos
+			level.emit(TurnEvent{Type: Move, Actor: remote.Name, ActorID: remote.ID, TargetID: Nobody, Pos: newPos})
+			gameStruct.pickUp(&remote.Player, level)
+			level.scripts.enteredTile(level, &remote.Character)
+		}
+	case !level.scripts.interacted(level, &remote.Character, newPos):
+		checkDoor(level, newPos, &remote.Character)
+	}
+}
+
+// activeLevels is every level someone is playing on; monsters elsewhere sleep.
+func (gameStruct *Game) activeLevels() []*Level {
+	levels := make([]*Level, 0, 1)
+	if gameStruct.Local {
+		levels = append(levels, gameStruct.CurrentLevel)
+	}
+	for _, remote := range gameStruct.Remotes {
+		found := false
+		for _, l := range levels {
+			if l == remote.Level {
+				found = true
+				break
+			}
+		}
+		if !found {
+			levels = append(levels, remote.Level)
+		}
+	}
+	return levels
+}
+
+// playersOn lists the characters monsters on level may hunt.
+func (gameStruct *Game) playersOn(level *Level) []*Character {
+	players := make([]*Character, 0, 1)
+	if gameStruct.Local && level == gameStruct.CurrentLevel && level.Player.HP > 0 {
+		players = append(players, &level.Player.Character)
+	}
+	for _, remote := range gameStruct.Remotes {
+		if remote.Level == level {
+			players = append(players, &remote.Character)
+		}
+	}
+	return players
+}
+
+// RemotesOn lists the remote players currently on level.
+func (gameStruct *Game) RemotesOn(level *Level) []*RemotePlayer {
+	remotes := make([]*RemotePlayer, 0)
+	for _, remote := range gameStruct.Remotes {
+		if remote.Level == level {
+			remotes = append(remotes, remote)
+		}
+	}
+	return remotes
+}
+
+func (gameStruct *Game) playerAt(level *Level, pos Pos) *Character {
+	if gameStruct.Local && level == gameStruct.CurrentLevel && level.Player.Pos == pos {
+		return &level.Player.Character
+	}
+	for _, remote := range gameStruct.Remotes {
+		if remote.Level == level && remote.Pos == pos {
+			return &remote.Character
+		}
+	}
+	return nil
+}
+
+func (gameStruct *Game) freeTileNear(level *Level, start Pos) Pos {
+	frontier := []Pos{start}
+	visited := map[Pos]bool{start: true}
+	for len(frontier) > 0 {
+		current := frontier[0]
+		frontier = frontier[1:]
+		if canWalk(level, current) && gameStruct.playerAt(level, current) == nil && level.Portals[current] == nil {
+			return current
+		}
+		for _, next := range getNeighbors(level, current) {
+			if !visited[next] {
+				frontier = append(frontier, next)
+				visited[next] = true
+			}
+		}
+	}
+	return start
+}
+
+func (gameStruct *Game) removeDeadRemotes() {
+	for id, remote := range gameStruct.Remotes {
+		if remote.HP <= 0 {
+			delete(gameStruct.Remotes, id)
+			remote.Level.AddEvent(remote.Name + " died")
+		}
+	}
+}
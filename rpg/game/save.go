@@ -0,0 +1,183 @@
+package game
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+const saveFile = "save.json"
+
+// SaveSlots is how many save slots there are besides save.json, which is
+// slot 0.
+const SaveSlots = 3
+
+func slotFile(slot int) string {
+	if slot == 0 {
+		return saveFile
+	}
+	return "save-" + strconv.Itoa(slot) + ".json"
+}
+
+// SaveInfo describes what is in a save slot for the menus: the title of the
+// level the player was on, which empty slots have none of, and the turn.
+type SaveInfo struct {
+	Slot  int
+	Level string
+	Turn  int
+	Saved time.Time
+}
+
+// SavedGames describes every save slot, save.json first.
+func SavedGames() []SaveInfo {
+	return savedGames(mapDir)
+}
+
+func savedGames(dir string) []SaveInfo {
+	infos := make([]SaveInfo, SaveSlots+1)
+	for slot := range infos {
+		infos[slot].Slot = slot
+		path := filepath.Join(dir, slotFile(slot))
+		stat, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var save Save
+		if json.Unmarshal(data, &save) != nil {
+			continue
+		}
+		infos[slot].Level = save.Title
+		if save.Title == "" {
+			infos[slot].Level = save.Level
+		}
+		infos[slot].Turn = save.Turn
+		infos[slot].Saved = stat.ModTime()
+	}
+	return infos
+}
+
+// Save is the local player's progress, written to save.json when the game is
+// quit and picked up again by the next game. The levels themselves start
+// afresh.
+type Save struct {
+	Level    string                 `json:"level"`
+	Title    string                 `json:"title,omitempty"`
+	X        int                    `json:"x"`
+	Y        int                    `json:"y"`
+	HP       int                    `json:"hp"`
+	Strength int                    `json:"strength"`
+	Items    []string               `json:"items,omitempty"`
+	Gold     int                    `json:"gold,omitempty"`
+	Quests   map[string]*QuestState `json:"quests,omitempty"`
+	// Turn and Stock keep the shops where they were: Stock maps each shop's
+	// name to how many it has of each item.
+	Turn  int                       `json:"turn,omitempty"`
+	Stock map[string]map[string]int `json:"stock,omitempty"`
+	Stats *RunStats                 `json:"stats,omitempty"`
+}
+
+func (gameStruct *Game) save(name string) error {
+	level := gameStruct.CurrentLevel
+	p := level.Player
+	save := Save{Level: level.Name, Title: level.Title, X: p.X, Y: p.Y, HP: p.HP, Strength: p.Strength, Gold: p.Gold, Quests: gameStruct.Quests.States, Turn: gameStruct.Turn, Stats: gameStruct.Stats}
+	for _, item := range p.Items {
+		save.Items = append(save.Items, item.Name)
+	}
+	save.Stock = make(map[string]map[string]int)
+	for _, shop := range gameStruct.Economy.Shops {
+		save.Stock[shop.Name] = make(map[string]int)
+		for _, s := range shop.Stock {
+			save.Stock[shop.Name][s.Item] = s.Count
+		}
+	}
+
+	file, err := os.Create(filepath.Join(gameStruct.dir, name))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(save)
+}
+
+// entrance is where the player starts on level, or the first tile they can
+// walk on if it has no start.
+func (level *Level) entrance() Pos {
+	if level.HasStart {
+		return level.Start
+	}
+	for y, row := range level.Map {
+		for x := range row {
+			if canWalk(level, Pos{x, y}) {
+				return Pos{x, y}
+			}
+		}
+	}
+	return level.Start
+}
+
+// loadSave carries on from the named save file, if there is one.
+func (gameStruct *Game) loadSave(name string) {
+	file, err := os.Open(filepath.Join(gameStruct.dir, name))
+	if os.IsNotExist(err) {
+		return
+	}
+	if err != nil {
+		panic(err)
+	}
+	defer file.Close()
+
+	var save Save
+	err = json.NewDecoder(file).Decode(&save)
+	if err != nil {
+		panic(err)
+	}
+
+	level := gameStruct.Levels[save.Level]
+	if level == nil {
+		return
+	}
+	gameStruct.CurrentLevel = level
+	p := &level.Player
+	p.Pos = Pos{X: save.X, Y: save.Y}
+	if !canWalk(level, p.Pos) {
+		// A save from before the map was edited may put the player in a
+		// wall or off the map.
+		p.Pos = gameStruct.freeTileNear(level, level.entrance())
+	}
+	p.HP = save.HP
+	p.Strength = save.Strength
+	p.Gold = save.Gold
+	p.Items = nil
+	for _, name := range save.Items {
+		p.give(name)
+	}
+	gameStruct.Turn = save.Turn
+	if save.Stats != nil {
+		gameStruct.Stats = save.Stats
+		if gameStruct.Stats.Kills == nil {
+			gameStruct.Stats.Kills = make(map[string]int)
+		}
+	}
+	for _, shop := range gameStruct.Economy.Shops {
+		for i := range shop.Stock {
+			count, exists := save.Stock[shop.Name][shop.Stock[i].Item]
+			if exists {
+				shop.Stock[i].Count = count
+			}
+		}
+	}
+	for name, state := range save.Quests {
+		q := gameStruct.Quests.quest(name)
+		if q != nil && len(state.Progress) == len(q.Objectives) {
+			gameStruct.Quests.States[name] = state
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package game
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeSave writes save to save.json, as if saved from another version of
+// the maps.
+func (g *testGame) writeSave(save Save) {
+	g.t.Helper()
+	data, err := json.Marshal(save)
+	if err != nil {
+		g.t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(g.dir, saveFile), data, 0644)
+	if err != nil {
+		g.t.Fatal(err)
+	}
+}
+
+func TestSaveRoundTrip(t *testing.T) {
+	g := newHermitGame(t)
+	g.play(Left)
+	g.Step(&Input{Type: Choose, Choice: 0})
+	for i := 0; i < 20 && len(g.CurrentLevel.Monsters) > 0; i++ {
+		g.play(Right)
+	}
+	g.play(Right, Right, Right)
+	p := g.player()
+	p.HP = 7
+	p.Gold = 12
+	p.give("Key")
+	err := g.save(saveFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := *p
+	quests := g.Quests.States["rat"]
+	turn := g.Turn
+	kills := g.Stats.Kills["Rat"]
+
+	g.control(&Input{Type: LoadGame})
+	g.wantPlayerAt("b", Pos{1, 1})
+	p = g.player()
+	if p.HP != want.HP || p.Strength != want.Strength || p.Gold != want.Gold {
+		t.Errorf("player %+v after loading, want %+v", p.Character, want.Character)
+	}
+	if len(p.Items) != 1 || p.Items[0].Name != "Key" {
+		t.Errorf("items %v after loading, want the key", p.Items)
+	}
+	if !reflect.DeepEqual(g.Quests.States["rat"], quests) {
+		t.Errorf("quest %+v after loading, want %+v", g.Quests.States["rat"], quests)
+	}
+	if g.Turn != turn || g.Stats.Kills["Rat"] != kills {
+		t.Errorf("turn %d and %d rats killed after loading, want %d and %d", g.Turn, g.Stats.Kills["Rat"], turn, kills)
+	}
+}
+
+func TestLoadSaveOffTheMap(t *testing.T) {
+	g := newTestGame(t, "a", map[string]string{"a": `
+		######
+		#.@..#
+		######
+	`})
+	for _, pos := range []Pos{{40, 1}, {-1, 1}, {0, 0}, {3, 2}} {
+		g.writeSave(Save{Level: "a", X: pos.X, Y: pos.Y, HP: 20, Strength: 20})
+		g.control(&Input{Type: LoadGame})
+		g.wantPlayerAt("a", Pos{2, 1})
+	}
+}
+
+func TestLoadSaveOffLevelWithoutStart(t *testing.T) {
+	g := newTestGame(t, "a", map[string]string{
+		"a": `
+			####
+			#@.#
+			####
+		`,
+		"b": `
+			#####
+			##..#
+			#####
+		`,
+	})
+	g.writeSave(Save{Level: "b", X: 0, Y: 0, HP: 20, Strength: 20})
+	g.control(&Input{Type: LoadGame})
+	g.wantPlayerAt("b", Pos{2, 1})
+}
@@ -0,0 +1,186 @@
+package game
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"go.starlark.net/starlark"
+)
+
+// Content scripts are Starlark files in the scripts directory next to the
+// maps. They run once when the game loads and register hooks with two
+// builtins:
+//
+//	on("enter_tile", fn)  # fn(level, who, x, y) after who steps onto x, y
+//	on("kill", fn)        # fn(level, killer, victim) when victim dies,
+//	                      # killer being None if nobody killed it
+//	on("interact", fn)    # fn(level, who, x, y) when who bumps into a wall
+//	                      # or door at x, y, or searches where they stand
+//	ai("Rat", fn)         # fn(level, monster, players) on each Rat's turn
+//
+// An interact hook returning True stops the engine doing what it would have
+// done, such as opening a door, and an ai hook returning True replaces the
+// monster's usual chase for that turn. The level and character values are
+// described in scriptapi.go.
+//
+// Scripts can't reach anything the game doesn't hand them, and each hook
+// call gets a limited number of steps. A hook that fails is reported in the
+// level's event log and otherwise ignored.
+const scriptDir = "scripts"
+
+// maxScriptSteps is how many Starlark steps a single hook call may take.
+const maxScriptSteps = 100000
+
+type scripts struct {
+	enterTile []starlark.Callable
+	kill      []starlark.Callable
+	interact  []starlark.Callable
+	ai        map[string]starlark.Callable
+	// players lists the players on a level, who set_tile mustn't wall in.
+	players func(level *Level) []*Character
+}
+
+var scriptEvents = []string{"enter_tile", "kill", "interact"}
+
+// loadScripts runs every .star file in dir/scripts, in name order. It
+// returns nil scripts, which have no hooks, if there is no such directory.
+func loadScripts(dir string) (*scripts, error) {
+	files, err := filepath.Glob(filepath.Join(dir, scriptDir, "*.star"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+	sort.Strings(files)
+
+	s := &scripts{ai: make(map[string]starlark.Callable)}
+	on := starlark.NewBuiltin("on", func(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var event string
+		var fn starlark.Callable
+		err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 2, &event, &fn)
+		if err != nil {
+			return nil, err
+		}
+		switch event {
+		case "enter_tile":
+			s.enterTile = append(s.enterTile, fn)
+		case "kill":
+			s.kill = append(s.kill, fn)
+		case "interact":
+			s.interact = append(s.interact, fn)
+		default:
+			return nil, fmt.Errorf("unknown event %q, want one of %q", event, scriptEvents)
+		}
+		return starlark.None, nil
+	})
+	ai := starlark.NewBuiltin("ai", func(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var monster string
+		var fn starlark.Callable
+		err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 2, &monster, &fn)
+		if err != nil {
+			return nil, err
+		}
+		if monsterTypes[monster] == nil {
+			return nil, fmt.Errorf("unknown monster %q", monster)
+		}
+		s.ai[monster] = fn
+		return starlark.None, nil
+	})
+	predeclared := starlark.StringDict{"on": on, "ai": ai}
+
+	for _, file := range files {
+		thread := newScriptThread(file)
+		_, err := starlark.ExecFile(thread, file, nil, predeclared)
+		if err != nil {
+			if evalErr, ok := err.(*starlark.EvalError); ok {
+				return nil, fmt.Errorf("%s", evalErr.Backtrace())
+			}
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func newScriptThread(name string) *starlark.Thread {
+	thread := &starlark.Thread{
+		Name: name,
+		Print: func(thread *starlark.Thread, msg string) {
+			fmt.Fprintln(os.Stderr, thread.Name+": "+msg)
+		},
+	}
+	thread.SetMaxExecutionSteps(maxScriptSteps)
+	return thread
+}
+
+// call runs a hook set off by actor and reports whether it returned something
+// true.
+func (s *scripts) call(level *Level, actor *Character, fn starlark.Callable, args ...starlark.Value) bool {
+	outer := level.actor
+	level.actor = actor
+	defer func() { level.actor = outer }()
+	thread := newScriptThread(fn.Name())
+	result, err := starlark.Call(thread, fn, args, nil)
+	if err != nil {
+		level.AddEvent("Script error: " + err.Error())
+		fmt.Fprintln(os.Stderr, err)
+		return false
+	}
+	return bool(result.Truth())
+}
+
+func (s *scripts) enteredTile(level *Level, who *Character) {
+	if s == nil {
+		return
+	}
+	for _, fn := range s.enterTile {
+		s.call(level, who, fn, level.scriptValue(), level.characterValue(who), starlark.MakeInt(who.X), starlark.MakeInt(who.Y))
+	}
+}
+
+func (s *scripts) killed(level *Level, killer, victim *Character) {
+	if s == nil {
+		return
+	}
+	var killerValue starlark.Value = starlark.None
+	if killer != nil {
+		killerValue = level.characterValue(killer)
+	}
+	for _, fn := range s.kill {
+		s.call(level, killer, fn, level.scriptValue(), killerValue, level.characterValue(victim))
+	}
+}
+
+// interacted runs the interact hooks and reports whether any of them handled
+// the interaction.
+func (s *scripts) interacted(level *Level, who *Character, pos Pos) bool {
+	if s == nil {
+		return false
+	}
+	handled := false
+	for _, fn := range s.interact {
+		if s.call(level, who, fn, level.scriptValue(), level.characterValue(who), starlark.MakeInt(pos.X), starlark.MakeInt(pos.Y)) {
+			handled = true
+		}
+	}
+	return handled
+}
+
+// monsterTurn runs m's ai hook, if it has one, and reports whether it took
+// m's turn.
+func (s *scripts) monsterTurn(level *Level, m *Monster, players []*Character) bool {
+	if s == nil {
+		return false
+	}
+	fn, exists := s.ai[m.Name]
+	if !exists {
+		return false
+	}
+	playerValues := make([]starlark.Value, len(players))
+	for i, p := range players {
+		playerValues[i] = level.characterValue(p)
+	}
+	return s.call(level, &m.Character, fn, level.scriptValue(), level.monsterValue(m, players), starlark.NewList(playerValues))
+}
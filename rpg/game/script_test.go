@@ -0,0 +1,175 @@
+package game
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScriptEnterTileSetsTile(t *testing.T) {
+	g := newTestGame(t, "a", map[string]string{"a": `
+		######
+		#@...#
+		######
+	`})
+	g.script(`
+def shut(level, who, x, y):
+    if who.is_player and x == 2:
+        level.set_tile(3, 1, "|")
+
+on("enter_tile", shut)
+`)
+
+	g.play(Right)
+	if g.CurrentLevel.Map[1][3].OverlayRune != ClosedDoor {
+		t.Errorf("tile 3,1 is %+v, want a closed door", g.CurrentLevel.Map[1][3])
+	}
+	g.play(Right)
+	g.wantPlayerAt("a", Pos{2, 1})
+	g.wantTurnEvent(DoorOpen, "Dralanor")
+}
+
+func TestScriptSetTileLeavesCharactersAndLayout(t *testing.T) {
+	g := newTestGame(t, "a", map[string]string{"a": `
+		######
+		#@.R.#
+		######
+	`})
+	g.script(`
+def build(level, who, x, y):
+    level.log("rat %s" % level.set_tile(3, 1, "#"))
+    level.log("player %s" % level.set_tile(1, 1, "|"))
+    level.log("floor %s" % level.set_tile(4, 1, "#"))
+    return True
+
+on("interact", build)
+`)
+	before := writeMap(t, g.CurrentLevel)
+
+	g.play(Search)
+	g.wantEvent("rat False")
+	g.wantEvent("player False")
+	g.wantEvent("floor True")
+	level := g.CurrentLevel
+	if len(level.Monsters) != 1 || level.Map[1][3].Rune != DirtFloor {
+		t.Errorf("rat's tile is %+v with monsters %v, want the rat left on floor", level.Map[1][3], level.Monsters)
+	}
+	if level.Map[1][1].OverlayRune != Blank || level.Map[1][4].Rune != StoneWall {
+		t.Errorf("tiles %+v and %+v, want the player's untouched and a wall at 4,1", level.Map[1][1], level.Map[1][4])
+	}
+	if !level.HasStart || level.Start != (Pos{1, 1}) {
+		t.Errorf("start %v %v, want it kept at 1,1", level.HasStart, level.Start)
+	}
+	if got := writeMap(t, level); got != before {
+		t.Errorf("WriteMap after set_tile:\n%s\nwant the map as loaded:\n%s", got, before)
+	}
+}
+
+func TestScriptKillIsAKill(t *testing.T) {
+	g := newTestGame(t, "a", map[string]string{"a": `
+		#####
+		#@.R#
+		#####
+	`})
+	g.script(`
+def search(level, who, x, y):
+    level.monster_at(3, 1).hp = 0
+    return True
+
+def killed(level, killer, victim):
+    level.log(killer.name + " got " + victim.name)
+
+on("interact", search)
+on("kill", killed)
+`)
+
+	g.play(Search)
+	g.wantTurnEvent(Death, "Dralanor")
+	g.wantEvent("Dralanor got Rat")
+	if len(g.CurrentLevel.Monsters) != 0 || g.CurrentLevel.Corpses[Pos{3, 1}] == nil {
+		t.Errorf("rat not buried, monsters %v corpses %v", g.CurrentLevel.Monsters, g.CurrentLevel.Corpses)
+	}
+	if g.Stats.Kills["Rat"] != 1 {
+		t.Errorf("kills %v, want the rat", g.Stats.Kills)
+	}
+}
+
+func TestScriptKilledMonsterLosesItsTurn(t *testing.T) {
+	g := newTestGame(t, "a", map[string]string{"a": `
+		########
+		#@...R.#
+		#.....S#
+		########
+	`})
+	g.script(`
+def rat(level, monster, players):
+    for m in level.monsters():
+        if m.name == "Spider":
+            m.hp = 0
+    return True
+
+ai("Rat", rat)
+`)
+
+	g.play(Search)
+	if len(g.CurrentLevel.Monsters) != 1 || g.CurrentLevel.Monsters[Pos{5, 1}] == nil {
+		t.Errorf("monsters %v, want only the rat", g.CurrentLevel.Monsters)
+	}
+	if g.CurrentLevel.Corpses[Pos{6, 2}] == nil {
+		t.Errorf("corpses %v, want the spider's where it died", g.CurrentLevel.Corpses)
+	}
+	for _, e := range g.CurrentLevel.TurnEvents {
+		if e.Type == Death && e.Actor != "Rat" {
+			t.Errorf("death %+v, want the rat to get the kill", e)
+		}
+	}
+}
+
+func TestScriptStepBudget(t *testing.T) {
+	g := newTestGame(t, "a", map[string]string{"a": `
+		####
+		#@.#
+		####
+	`})
+	g.script(`
+def forever(level, who, x, y):
+    for i in range(1000000000):
+        pass
+
+on("enter_tile", forever)
+`)
+
+	g.play(Right)
+	g.wantPlayerAt("a", Pos{2, 1})
+	g.wantScriptError("too many steps")
+}
+
+func TestScriptError(t *testing.T) {
+	g := newTestGame(t, "a", map[string]string{"a": `
+		####
+		#@.#
+		####
+	`})
+	g.script(`
+def broken(level, who, x, y):
+    level.set_tile(x, y, "x")
+    return True
+
+on("interact", broken)
+`)
+
+	g.play(Search)
+	g.wantScriptError(`"x" isn't terrain`)
+	g.play(Right)
+	g.wantPlayerAt("a", Pos{2, 1})
+}
+
+// wantScriptError checks that a script error mentioning msg was logged.
+func (g *testGame) wantScriptError(msg string) {
+	g.t.Helper()
+	for _, e := range g.events() {
+		if strings.HasPrefix(e, "Script error: ") && strings.Contains(e, msg) {
+			return
+		}
+	}
+	g.t.Errorf("no script error about %q in %q", msg, g.events())
+}
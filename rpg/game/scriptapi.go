@@ -0,0 +1,398 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.starlark.net/starlark"
+)
+
+// The values scripts see. A level has
+//
+//	name, title, depth, width, height
+//	tile(x, y)            the map character of the terrain at x, y, or "" off the map
+//	set_tile(x, y, ch)    changes the terrain, one of "#.|/ud" or " ", and
+//	                      reports whether it could; it won't wall anyone in
+//	walkable(x, y)
+//	monster_at(x, y)      the monster there, or None
+//	monsters()            every monster on the level
+//	spawn(name, x, y)     puts a new monster, such as "Rat", on a walkable tile
+//	log(msg)              adds msg to the event log
+//	events()              the messages in the event log, oldest first
+//
+// and a character
+//
+//	name, x, y, is_player, is_monster
+//	hp, strength          which scripts may change; a monster with no hp left
+//	                      dies, killed by whoever set off the hook
+//
+// The monster handed to an ai hook can also
+//
+//	step(dx, dy)          move or attack one tile, using one action point,
+//	                      and report whether it could
+//	path_to(x, y)         the tiles on the shortest way to x, y as (x, y)
+//	                      tuples, not counting where it is now
+//	ap                    the action points it has left this turn
+
+const terrainRunes = "#.|/ud "
+
+type levelValue struct {
+	level *Level
+}
+
+func (level *Level) scriptValue() *levelValue {
+	return &levelValue{level: level}
+}
+
+func (v *levelValue) String() string        { return "<level " + v.level.Name + ">" }
+func (v *levelValue) Type() string          { return "level" }
+func (v *levelValue) Freeze()               {}
+func (v *levelValue) Truth() starlark.Bool  { return starlark.True }
+func (v *levelValue) Hash() (uint32, error) { return 0, fmt.Errorf("unhashable: level") }
+
+var levelMethods = map[string]func(level *Level, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error){
+	"tile":       levelTile,
+	"set_tile":   levelSetTile,
+	"walkable":   levelWalkable,
+	"monster_at": levelMonsterAt,
+	"monsters":   levelMonsters,
+	"spawn":      levelSpawn,
+	"log":        levelLog,
+	"events":     levelEvents,
+}
+
+func (v *levelValue) Attr(name string) (starlark.Value, error) {
+	level := v.level
+	switch name {
+	case "name":
+		return starlark.String(level.Name), nil
+	case "title":
+		return starlark.String(level.Title), nil
+	case "depth":
+		return starlark.MakeInt(level.Depth), nil
+	case "width":
+		return starlark.MakeInt(len(level.Map[0])), nil
+	case "height":
+		return starlark.MakeInt(len(level.Map)), nil
+	}
+	method, exists := levelMethods[name]
+	if !exists {
+		return nil, nil
+	}
+	return starlark.NewBuiltin(name, func(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		return method(level, b, args, kwargs)
+	}), nil
+}
+
+func (v *levelValue) AttrNames() []string {
+	names := []string{"name", "title", "depth", "width", "height"}
+	for name := range levelMethods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func unpackPos(b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (Pos, error) {
+	var pos Pos
+	err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 2, &pos.X, &pos.Y)
+	return pos, err
+}
+
+func levelTile(level *Level, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	pos, err := unpackPos(b, args, kwargs)
+	if err != nil {
+		return nil, err
+	}
+	if !inRange(level, pos) {
+		return starlark.String(""), nil
+	}
+	t := level.Map[pos.Y][pos.X]
+	switch {
+	case t.OverlayRune != Blank:
+		return starlark.String(string(t.OverlayRune)), nil
+	case t.Rune == Blank:
+		return starlark.String(" "), nil
+	}
+	return starlark.String(string(t.Rune)), nil
+}
+
+func levelSetTile(level *Level, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var pos Pos
+	var character string
+	err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 3, &pos.X, &pos.Y, &character)
+	if err != nil {
+		return nil, err
+	}
+	if len(character) != 1 || !strings.Contains(terrainRunes, character) {
+		return nil, fmt.Errorf("%q isn't terrain, want one of %q", character, terrainRunes)
+	}
+	if !inRange(level, pos) {
+		return nil, fmt.Errorf("%d, %d is off the map", pos.X, pos.Y)
+	}
+	t := &level.Map[pos.Y][pos.X]
+	base, overlay := Pending, Blank
+	switch character[0] {
+	case ' ':
+		base = Blank
+	case '#':
+		base = StoneWall
+	case '.':
+		base = DirtFloor
+	case '|':
+		overlay = ClosedDoor
+	case '/':
+		overlay = OpenDoor
+	case 'u':
+		overlay = UpStair
+	case 'd':
+		overlay = DownStair
+	}
+	if (base == StoneWall || base == Blank || overlay == ClosedDoor) && level.standing(pos) {
+		return starlark.False, nil
+	}
+	t.Rune, t.OverlayRune = base, overlay
+	if base == Pending {
+		t.Rune = level.bfsFloor(pos)
+	}
+	level.lineOfSight()
+	return starlark.True, nil
+}
+
+// standing reports whether a character is at pos.
+func (level *Level) standing(pos Pos) bool {
+	if level.Monsters[pos] != nil || level.NPCs[pos] != nil {
+		return true
+	}
+	for _, p := range level.scripts.players(level) {
+		if p.Pos == pos {
+			return true
+		}
+	}
+	return false
+}
+
+func levelWalkable(level *Level, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	pos, err := unpackPos(b, args, kwargs)
+	if err != nil {
+		return nil, err
+	}
+	return starlark.Bool(canWalk(level, pos)), nil
+}
+
+func levelMonsterAt(level *Level, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	pos, err := unpackPos(b, args, kwargs)
+	if err != nil {
+		return nil, err
+	}
+	monster, exists := level.Monsters[pos]
+	if !exists {
+		return starlark.None, nil
+	}
+	return level.characterValue(&monster.Character), nil
+}
+
+func levelMonsters(level *Level, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 0)
+	if err != nil {
+		return nil, err
+	}
+	monsters := make([]*Monster, 0, len(level.Monsters))
+	for _, monster := range level.Monsters {
+		monsters = append(monsters, monster)
+	}
+	sort.Slice(monsters, func(i, j int) bool { return monsters[i].ID < monsters[j].ID })
+	values := make([]starlark.Value, len(monsters))
+	for i, monster := range monsters {
+		values[i] = level.characterValue(&monster.Character)
+	}
+	return starlark.NewList(values), nil
+}
+
+func levelSpawn(level *Level, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var name string
+	var pos Pos
+	err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 3, &name, &pos.X, &pos.Y)
+	if err != nil {
+		return nil, err
+	}
+	if monsterTypes[name] == nil {
+		return nil, fmt.Errorf("unknown monster %q", name)
+	}
+	if !canWalk(level, pos) {
+		return starlark.None, nil
+	}
+	monster := level.addMonster(name, pos)
+	return level.characterValue(&monster.Character), nil
+}
+
+func levelLog(level *Level, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var msg string
+	err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &msg)
+	if err != nil {
+		return nil, err
+	}
+	level.AddEvent(msg)
+	return starlark.None, nil
+}
+
+func levelEvents(level *Level, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 0)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]starlark.Value, 0, len(level.Events))
+	for i := range level.Events {
+		event := level.Events[(level.EventPos+i)%len(level.Events)]
+		if event != "" {
+			values = append(values, starlark.String(event))
+		}
+	}
+	return starlark.NewList(values), nil
+}
+
+type characterValue struct {
+	level     *Level
+	character *Character
+	// monster is set when the character is a monster on level.
+	monster *Monster
+}
+
+func (level *Level) characterValue(c *Character) *characterValue {
+	v := &characterValue{level: level, character: c}
+	monster, exists := level.Monsters[c.Pos]
+	if exists && &monster.Character == c {
+		v.monster = monster
+	}
+	return v
+}
+
+func (v *characterValue) String() string        { return "<character " + v.character.Name + ">" }
+func (v *characterValue) Type() string          { return "character" }
+func (v *characterValue) Freeze()               {}
+func (v *characterValue) Truth() starlark.Bool  { return starlark.True }
+func (v *characterValue) Hash() (uint32, error) { return 0, fmt.Errorf("unhashable: character") }
+
+func (v *characterValue) Attr(name string) (starlark.Value, error) {
+	c := v.character
+	switch name {
+	case "name":
+		return starlark.String(c.Name), nil
+	case "x":
+		return starlark.MakeInt(c.X), nil
+	case "y":
+		return starlark.MakeInt(c.Y), nil
+	case "hp":
+		return starlark.MakeInt(c.HP), nil
+	case "strength":
+		return starlark.MakeInt(c.Strength), nil
+	case "is_player":
+		return starlark.Bool(v.monster == nil), nil
+	case "is_monster":
+		return starlark.Bool(v.monster != nil), nil
+	}
+	return nil, nil
+}
+
+func (v *characterValue) AttrNames() []string {
+	return []string{"hp", "is_monster", "is_player", "name", "strength", "x", "y"}
+}
+
+func (v *characterValue) SetField(name string, value starlark.Value) error {
+	n, err := starlark.AsInt32(value)
+	if err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	switch name {
+	case "hp":
+		alive := v.character.HP > 0
+		v.character.HP = n
+		if v.monster != nil && alive && n <= 0 && v.level.Monsters[v.monster.Pos] == v.monster {
+			// Whoever set off the hook gets the kill, unless it was the
+			// monster itself.
+			killer := v.level.actor
+			if killer == v.character {
+				killer = nil
+			}
+			v.level.died(killer, v.character)
+		}
+	case "strength":
+		v.character.Strength = n
+	default:
+		return starlark.NoSuchAttrError(fmt.Sprintf("character has no settable field .%s", name))
+	}
+	return nil
+}
+
+// monsterValue is the monster an ai hook is deciding for.
+type monsterValue struct {
+	*characterValue
+	players []*Character
+}
+
+func (level *Level) monsterValue(m *Monster, players []*Character) *monsterValue {
+	return &monsterValue{characterValue: &characterValue{level: level, character: &m.Character, monster: m}, players: players}
+}
+
+func (v *monsterValue) Attr(name string) (starlark.Value, error) {
+	switch name {
+	case "ap":
+		return starlark.Float(v.monster.AP), nil
+	case "step":
+		return starlark.NewBuiltin(name, v.step), nil
+	case "path_to":
+		return starlark.NewBuiltin(name, v.pathTo), nil
+	}
+	return v.characterValue.Attr(name)
+}
+
+func (v *monsterValue) AttrNames() []string {
+	names := append(v.characterValue.AttrNames(), "ap", "path_to", "step")
+	sort.Strings(names)
+	return names
+}
+
+func (v *monsterValue) step(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var dx, dy int
+	err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 2, &dx, &dy)
+	if err != nil {
+		return nil, err
+	}
+	if dx*dx+dy*dy != 1 {
+		return nil, fmt.Errorf("%d, %d isn't one step up, down, left or right", dx, dy)
+	}
+	m := v.monster
+	if m.AP < 1 || m.HP <= 0 || v.level.Monsters[m.Pos] != m {
+		return starlark.False, nil
+	}
+	to := Pos{X: m.X + dx, Y: m.Y + dy}
+	target := false
+	for _, p := range v.players {
+		if p.Pos == to {
+			target = true
+		}
+	}
+	if !target && !canWalk(v.level, to) {
+		return starlark.False, nil
+	}
+	m.Move(to, v.level, v.players)
+	m.AP--
+	return starlark.True, nil
+}
+
+func (v *monsterValue) pathTo(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	goal, err := unpackPos(b, args, kwargs)
+	if err != nil {
+		return nil, err
+	}
+	path := v.level.astar(v.monster.Pos, goal)
+	values := make([]starlark.Value, 0, len(path))
+	for i, pos := range path {
+		if i == 0 {
+			continue
+		}
+		values = append(values, starlark.Tuple{starlark.MakeInt(pos.X), starlark.MakeInt(pos.Y)})
+	}
+	return starlark.NewList(values), nil
+}
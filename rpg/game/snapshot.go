@@ -0,0 +1,107 @@
+package game
+
+// Snapshot is a read-only copy of a Level taken at the end of a turn. The game
+// goroutine keeps mutating its Level after publishing, so UIs only ever see
+// snapshots and nothing in a snapshot is shared with the live level.
+type Snapshot struct {
+	Name       string
+	Title      string
+	Depth      int
+	Music      string
+	Map        [][]Tile
+	Player     Player
+	Monsters   map[Pos]*Monster
+	Corpses    map[Pos]*Corpse
+	Loot       map[Pos]*Loot
+	NPCs       map[Pos]*NPC
+	Others     map[Pos]*Player
+	Portals    map[Pos]PortalDest
+	Events     []string
+	EventPos   int
+	TurnEvents []TurnEvent
+	Debug      map[Pos]bool
+	// Dialogue is the conversation the local player is having, if any, Shop
+	// the shop they are in and Quests the quests they have started. Travel is
+	// where they are walking to, if they clicked somewhere.
+	Dialogue *DialogueView
+	Shop     *ShopView
+	Quests   []QuestView
+	Travel   *Pos
+}
+
+// PortalDest is where a portal leads, by level name so that snapshots don't
+// point back into live levels.
+type PortalDest struct {
+	Level string
+	Pos   Pos
+}
+
+// copy is the player with their own copy of every item.
+func (p *Player) copy() Player {
+	c := *p
+	c.Items = make([]*Item, len(p.Items))
+	for i, item := range p.Items {
+		it := *item
+		c.Items[i] = &it
+	}
+	return c
+}
+
+func (level *Level) Snapshot() *Snapshot {
+	snap := &Snapshot{Name: level.Name, Title: level.Title, Depth: level.Depth, Music: level.Music}
+
+	snap.Map = make([][]Tile, len(level.Map))
+	for y, row := range level.Map {
+		snap.Map[y] = make([]Tile, len(row))
+		copy(snap.Map[y], row)
+	}
+
+	snap.Player = level.Player.copy()
+
+	snap.Monsters = make(map[Pos]*Monster, len(level.Monsters))
+	for pos, monster := range level.Monsters {
+		m := *monster
+		snap.Monsters[pos] = &m
+	}
+
+	snap.Corpses = make(map[Pos]*Corpse, len(level.Corpses))
+	for pos, corpse := range level.Corpses {
+		c := *corpse
+		snap.Corpses[pos] = &c
+	}
+
+	snap.Loot = make(map[Pos]*Loot, len(level.Loot))
+	for pos, loot := range level.Loot {
+		l := *loot
+		l.Items = append([]string(nil), loot.Items...)
+		snap.Loot[pos] = &l
+	}
+
+	snap.NPCs = make(map[Pos]*NPC, len(level.NPCs))
+	for pos, npc := range level.NPCs {
+		n := *npc
+		snap.NPCs[pos] = &n
+	}
+
+	snap.Others = make(map[Pos]*Player)
+
+	snap.Portals = make(map[Pos]PortalDest, len(level.Portals))
+	for pos, dest := range level.Portals {
+		snap.Portals[pos] = PortalDest{Level: dest.Level.Name, Pos: dest.Pos}
+	}
+
+	snap.Events = make([]string, len(level.Events))
+	copy(snap.Events, level.Events)
+	snap.EventPos = level.EventPos
+	snap.TurnEvents = make([]TurnEvent, len(level.TurnEvents))
+	copy(snap.TurnEvents, level.TurnEvents)
+
+	if level.Debug != nil {
+		snap.Debug = make(map[Pos]bool, len(level.Debug))
+		for pos, b := range level.Debug {
+			snap.Debug[pos] = b
+		}
+	}
+
+	return snap
+}
@@ -0,0 +1,76 @@
+package game
+
+import "testing"
+
+func TestSnapshotCopiesRemotes(t *testing.T) {
+	g := newTestGame(t, "a", map[string]string{"a": `
+		#####
+		#@..#
+		#####
+	`})
+	remote := g.AddPlayer("Ana")
+	remote.give("Key")
+	levelChan := make(chan *Snapshot, 1)
+	g.LevelChans = []chan *Snapshot{levelChan}
+
+	g.publish()
+	snap := <-levelChan
+	other := snap.Others[remote.Pos]
+	if other == nil || len(other.Items) != 1 {
+		t.Fatalf("others %v, want Ana with her key", snap.Others)
+	}
+	if other.Items[0] == remote.Items[0] {
+		t.Errorf("snapshot shares Ana's items with the game")
+	}
+}
+
+// TestRunConcurrently plays the game loop as the UI does, looking through
+// every snapshot in another goroutine while the game plays on, so that
+// go test -race finds anything the two share.
+func TestRunConcurrently(t *testing.T) {
+	g := newTestGame(t, "a", map[string]string{"a": `
+		#########
+		#@.....R#
+		#.......#
+		#S......#
+		#########
+	`})
+	remote := g.AddPlayer("Ana")
+	remote.give("Key")
+	levelChan, done := g.run()
+
+	looked := make(chan int)
+	go func() {
+		n := 0
+		for snap := range levelChan {
+			for _, row := range snap.Map {
+				for _, tile := range row {
+					n += int(tile.Rune)
+				}
+			}
+			for _, monster := range snap.Monsters {
+				n += monster.HP
+			}
+			for _, other := range snap.Others {
+				for _, item := range other.Items {
+					n += len(item.Name)
+				}
+			}
+			for _, item := range snap.Player.Items {
+				n += len(item.Name)
+			}
+			n += len(snap.TurnEvents) + len(snap.Events)
+		}
+		looked <- n
+	}()
+
+	for i := 0; i < 20; i++ {
+		g.InputChan <- &Input{Type: []InputType{Right, Down, Left, Up}[i%4]}
+		g.InputChan <- &Input{Type: []InputType{Down, Right, Up, Left}[i%4], PlayerID: remote.ID}
+	}
+	g.InputChan <- &Input{Type: CloseWindow, LevelChannel: levelChan}
+	<-done
+	if <-looked == 0 {
+		t.Errorf("no snapshots seen")
+	}
+}
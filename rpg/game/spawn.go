@@ -0,0 +1,190 @@
+package game
+
+// Spawner is a nest or a crack in the wall that a monster crawls out of every
+// Every turns, onto its own tile or one next to it, until Max of the ones it
+// made are alive. Monster is left empty to pick one from the level's spawn
+// table.
+type Spawner struct {
+	X       int    `json:"x"`
+	Y       int    `json:"y"`
+	Monster string `json:"monster,omitempty"`
+	Every   int    `json:"every"`
+	Max     int    `json:"max"`
+}
+
+// spawnMonsters brings out new monsters on every level someone is playing
+// on, from its spawn table and its spawners, as long as the level isn't full.
+func (gameStruct *Game) spawnMonsters() {
+	for _, level := range gameStruct.activeLevels() {
+		players := gameStruct.playersOn(level)
+		if level.SpawnEvery > 0 && gameStruct.Turn%level.SpawnEvery == 0 {
+			gameStruct.spawnOutOfSight(level, players)
+		}
+		for i, spawner := range level.Spawners {
+			if spawner.Every > 0 && gameStruct.Turn%spawner.Every == 0 {
+				gameStruct.spawnFrom(level, i, players)
+			}
+		}
+	}
+}
+
+// room is how many more monsters the level can hold.
+func (level *Level) room() int {
+	if level.MaxMonsters <= 0 {
+		return 0
+	}
+	return level.MaxMonsters - len(level.Monsters)
+}
+
+// spawnOutOfSight brings a group from the spawn table onto a tile none of the
+// players can see.
+func (gameStruct *Game) spawnOutOfSight(level *Level, players []*Character) {
+	if level.room() <= 0 {
+		return
+	}
+	spawn := gameStruct.pickSpawn(level)
+	if spawn == nil {
+		return
+	}
+	hidden := func(pos Pos) bool {
+		return level.canSpawnAt(pos, players) && !level.inSight(pos, players)
+	}
+	candidates := make([]Pos, 0)
+	for y, row := range level.Map {
+		for x := range row {
+			if hidden(Pos{x, y}) {
+				candidates = append(candidates, Pos{x, y})
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return
+	}
+	group := 1
+	if spawn.Group > 1 {
+		group += gameStruct.rand.Intn(spawn.Group)
+	}
+	start := candidates[gameStruct.rand.Intn(len(candidates))]
+	for _, pos := range level.nearbyTiles(start, group, hidden) {
+		if level.room() <= 0 {
+			return
+		}
+		level.addMonster(spawn.Monster, pos)
+	}
+}
+
+// spawnFrom has the level's i'th spawner bring out a monster, if it hasn't
+// already got Max of them about.
+func (gameStruct *Game) spawnFrom(level *Level, i int, players []*Character) {
+	spawner := level.Spawners[i]
+	alive := 0
+	for _, monster := range level.Monsters {
+		if monster.spawner == i+1 {
+			alive++
+		}
+	}
+	if alive >= spawner.Max || level.room() <= 0 {
+		return
+	}
+	name := spawner.Monster
+	if name == "" {
+		spawn := gameStruct.pickSpawn(level)
+		if spawn == nil {
+			return
+		}
+		name = spawn.Monster
+	}
+	free := func(pos Pos) bool {
+		return level.canSpawnAt(pos, players)
+	}
+	for _, pos := range level.nearbyTiles(Pos{spawner.X, spawner.Y}, 1, free) {
+		level.addMonster(name, pos).spawner = i + 1
+	}
+}
+
+// pickSpawn picks an entry from the level's spawn table at random, by its
+// weight at the level's depth, or returns nil if there is nothing to pick.
+func (gameStruct *Game) pickSpawn(level *Level) *Spawn {
+	weight := func(spawn Spawn) int {
+		w := spawn.Weight
+		if level.Depth > 1 {
+			w += spawn.DepthWeight * (level.Depth - 1)
+		}
+		if w < 0 {
+			return 0
+		}
+		return w
+	}
+	total := 0
+	for _, spawn := range level.Spawns {
+		total += weight(spawn)
+	}
+	if total == 0 {
+		return nil
+	}
+	n := gameStruct.rand.Intn(total)
+	for i, spawn := range level.Spawns {
+		n -= weight(spawn)
+		if n < 0 {
+			return &level.Spawns[i]
+		}
+	}
+	return nil
+}
+
+// canSpawnAt reports whether a monster could appear at pos: somewhere it
+// could walk to, and not on a player or a portal.
+func (level *Level) canSpawnAt(pos Pos, players []*Character) bool {
+	if !canWalk(level, pos) {
+		return false
+	}
+	if _, isPortal := level.Portals[pos]; isPortal {
+		return false
+	}
+	for _, p := range players {
+		if p.Pos == pos {
+			return false
+		}
+	}
+	return true
+}
+
+// inSight reports whether any of the players can see pos.
+func (level *Level) inSight(pos Pos, players []*Character) bool {
+	for _, p := range players {
+		if level.canSee(p, pos) && level.clearLine(p.Pos, pos) {
+			return true
+		}
+	}
+	return false
+}
+
+// nearbyTiles finds up to n tiles for which ok is true, the closest to start
+// by walking first, starting with start itself.
+func (level *Level) nearbyTiles(start Pos, n int, ok func(Pos) bool) []Pos {
+	found := make([]Pos, 0, n)
+	visited := map[Pos]bool{start: true}
+	frontier := []Pos{start}
+	for len(frontier) > 0 && len(found) < n {
+		current := frontier[0]
+		frontier = frontier[1:]
+		if ok(current) {
+			found = append(found, current)
+		}
+		for _, next := range getNeighbors(level, current) {
+			if !visited[next] {
+				visited[next] = true
+				frontier = append(frontier, next)
+			}
+		}
+	}
+	return found
+}
+
+// addMonster puts a new monster called name on the level at pos.
+func (level *Level) addMonster(name string, pos Pos) *Monster {
+	monster := monsterTypes[name](pos)
+	monster.ID = level.ids.next()
+	level.Monsters[pos] = monster
+	return monster
+}
@@ -0,0 +1,83 @@
+package game
+
+import "testing"
+
+func TestSpawnOutOfSight(t *testing.T) {
+	g := newTestGame(t, "a", map[string]string{"a": `
+		#########
+		#@..#...#
+		#...|...#
+		#...#...#
+		#########
+	`})
+	level := g.CurrentLevel
+	level.Spawns = []Spawn{{Monster: "Rat", Weight: 1, Group: 3}}
+	level.SpawnEvery = 2
+	level.MaxMonsters = 5
+
+	for i := 0; i < 10; i++ {
+		g.play(Search)
+		for pos := range level.Monsters {
+			if pos.X < 4 {
+				t.Fatalf("rat spawned at %v in sight of the player", pos)
+			}
+		}
+	}
+	if len(level.Monsters) != 5 {
+		t.Errorf("%d monsters, want MaxMonsters 5", len(level.Monsters))
+	}
+}
+
+func TestSpawner(t *testing.T) {
+	g := newTestGame(t, "a", map[string]string{"a": `
+		#######
+		#@....#
+		#######
+	`})
+	level := g.CurrentLevel
+	level.MaxMonsters = 10
+	level.Spawners = []Spawner{{X: 5, Y: 1, Monster: "Spider", Every: 1, Max: 2}}
+
+	g.play(Search)
+	spider := level.Monsters[Pos{5, 1}]
+	if spider == nil || spider.Name != "Spider" {
+		t.Fatalf("no spider on the spawner, monsters %v", level.Monsters)
+	}
+	g.play(Search, Search, Search)
+	if len(level.Monsters) != 2 {
+		t.Errorf("%d monsters, want the spawner's Max of 2", len(level.Monsters))
+	}
+
+	delete(level.Monsters, spider.Pos)
+	g.play(Search)
+	if len(level.Monsters) != 2 {
+		t.Errorf("%d monsters after one was killed, want the spawner to make another", len(level.Monsters))
+	}
+}
+
+func TestPickSpawnByDepth(t *testing.T) {
+	g := newTestGame(t, "a", map[string]string{"a": `
+		###
+		#@#
+		###
+	`})
+	level := g.CurrentLevel
+	level.Spawns = []Spawn{{Monster: "Rat", Weight: 3, DepthWeight: -1}, {Monster: "Spider", Weight: 1}}
+
+	count := func(depth int) map[string]int {
+		level.Depth = depth
+		picked := make(map[string]int)
+		for i := 0; i < 1000; i++ {
+			picked[g.pickSpawn(level).Monster]++
+		}
+		return picked
+	}
+	shallow := count(1)
+	if shallow["Rat"] < 600 || shallow["Spider"] < 100 {
+		t.Errorf("picked %v at depth 1, want about three rats for every spider", shallow)
+	}
+	deep := count(4)
+	if deep["Rat"] != 0 {
+		t.Errorf("picked %v at depth 4, want only spiders", deep)
+	}
+}
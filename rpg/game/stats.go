@@ -0,0 +1,105 @@
+package game
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// RunStats is what the local player has done since starting out, carried
+// over in save.json when they quit and carry on later. Game.Turn counts the
+// turns.
+type RunStats struct {
+	Kills       map[string]int `json:"kills,omitempty"`
+	DamageDealt int            `json:"damageDealt"`
+	DamageTaken int            `json:"damageTaken"`
+	Deepest     int            `json:"deepest"`
+	// Explored is the number of tiles seen on all levels since the game was
+	// started, counted when it ends. The levels start afresh from a save, so
+	// it isn't kept in one.
+	Explored int `json:"-"`
+	// Died is set once the player is killed, by KilledBy.
+	Died     bool   `json:"-"`
+	KilledBy string `json:"-"`
+}
+
+func newRunStats() *RunStats {
+	return &RunStats{Kills: make(map[string]int)}
+}
+
+// record counts what the local player did and had done to them during the
+// turn just played.
+func (gameStruct *Game) record() {
+	stats := gameStruct.Stats
+	level := gameStruct.CurrentLevel
+	id := level.Player.ID
+	if level.Depth > stats.Deepest {
+		stats.Deepest = level.Depth
+	}
+	for _, e := range level.TurnEvents {
+		switch {
+		case e.Type == Hit && e.ActorID == id:
+			stats.DamageDealt += e.Damage
+		case e.Type == Hit && e.TargetID == id:
+			stats.DamageTaken += e.Damage
+		case e.Type == Death && e.ActorID == id:
+			stats.Kills[e.Target]++
+		case e.Type == Death && e.TargetID == id && !stats.Died:
+			stats.Died = true
+			stats.KilledBy = e.Actor
+			if e.ActorID == Nobody {
+				stats.KilledBy = "misfortune"
+			}
+		}
+	}
+	if level.Player.HP <= 0 && !stats.Died {
+		stats.Died = true
+		stats.KilledBy = "misfortune"
+	}
+}
+
+// explored counts the tiles seen on every level.
+func (gameStruct *Game) explored() int {
+	n := 0
+	for _, level := range gameStruct.Levels {
+		for _, row := range level.Map {
+			for _, tile := range row {
+				if tile.Seen && tile.Rune != Blank {
+					n++
+				}
+			}
+		}
+	}
+	return n
+}
+
+// KillCount is the number of monsters killed of every kind.
+func (stats *RunStats) KillCount() int {
+	n := 0
+	for _, count := range stats.Kills {
+		n += count
+	}
+	return n
+}
+
+// endRun writes the morgue file for a run that ended by cause, and when the
+// player died, puts the run in the high scores and forgets their save.
+func (gameStruct *Game) endRun(cause string) {
+	stats := gameStruct.Stats
+	stats.Explored = gameStruct.explored()
+	err := gameStruct.writeMorgue(cause, time.Now())
+	if err != nil {
+		panic(err)
+	}
+	if !stats.Died {
+		return
+	}
+	err = addHighScore(gameStruct.dir, gameStruct.highScore(cause, time.Now()))
+	if err != nil {
+		panic(err)
+	}
+	err = os.Remove(filepath.Join(gameStruct.dir, saveFile))
+	if err != nil && !os.IsNotExist(err) {
+		panic(err)
+	}
+}
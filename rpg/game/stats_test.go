@@ -0,0 +1,122 @@
+package game
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordKills(t *testing.T) {
+	g := newTestGame(t, "a", map[string]string{"a": `
+		####
+		#@S#
+		####
+	`})
+	g.play(Right, Right, Right, Right, Right)
+	g.wantEvent("Dralanor killed Spider")
+
+	stats := g.Stats
+	if stats.Kills["Spider"] != 1 || stats.KillCount() != 1 {
+		t.Errorf("kills %v, want one Spider", stats.Kills)
+	}
+	if stats.DamageDealt != 100 {
+		t.Errorf("dealt %d damage, want 100", stats.DamageDealt)
+	}
+	if stats.Died {
+		t.Errorf("player died with %d HP", g.player().HP)
+	}
+}
+
+func TestRecordIgnoresNamesake(t *testing.T) {
+	g := newTestGame(t, "a", map[string]string{"a": `
+		######
+		#@...#
+		######
+	`})
+	remote := g.AddPlayer("Dralanor")
+	spider := g.CurrentLevel.addMonster("Spider", Pos{remote.X + 1, remote.Y})
+	spider.HP = remote.Strength
+
+	g.Step(&Input{Type: Right, PlayerID: remote.ID})
+	death := g.wantTurnEvent(Death, "Dralanor")
+	if death.ActorID != remote.ID || death.TargetID != spider.ID {
+		t.Errorf("death %+v, want remote %d killing spider %d", death, remote.ID, spider.ID)
+	}
+	if g.Stats.KillCount() != 0 || g.Stats.DamageDealt != 0 {
+		t.Errorf("local run credited with %v kills and %d damage", g.Stats.Kills, g.Stats.DamageDealt)
+	}
+}
+
+func TestPlayerDies(t *testing.T) {
+	g := newTestGame(t, "a", map[string]string{"a": `
+		####
+		#@S#
+		####
+	`})
+	g.CurrentLevel.Monsters[Pos{2, 1}].Strength = 5
+	g.player().HP = 1
+	g.play(Search)
+
+	stats := g.Stats
+	if !stats.Died || stats.KilledBy != "Spider" {
+		t.Fatalf("died %v, killed by %q, want killed by Spider", stats.Died, stats.KilledBy)
+	}
+	if stats.DamageTaken != 5 {
+		t.Errorf("took %d damage, want 5", stats.DamageTaken)
+	}
+	g.wantEvent("Spider killed Dralanor")
+
+	// Nothing goes on attacking the dead.
+	hp := g.player().HP
+	g.play(Search)
+	g.wantHP(&g.player().Character, hp)
+}
+
+func TestMorgue(t *testing.T) {
+	g := newTestGame(t, "a", map[string]string{"a": `
+		#######
+		#@....#
+		#######
+
+		#######
+		#.....#
+		#######
+	`})
+	g.Stats.Kills["Rat"] = 3
+	g.Stats.Deepest = 2
+	morgue := g.morgue("quit", time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC))
+
+	for _, want := range []string{
+		"Dralanor, quit on a after 0 turns.",
+		"Deepest level 2",
+		"    3 Rat",
+		"#@....#",
+	} {
+		if !strings.Contains(morgue, want) {
+			t.Errorf("morgue has no %q in\n%s", want, morgue)
+		}
+	}
+	if strings.Contains(morgue, "#.....#") {
+		t.Errorf("morgue shows a room the player never saw:\n%s", morgue)
+	}
+}
+
+func TestRankHighScores(t *testing.T) {
+	day := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	scores := make([]HighScore, 0)
+	for i := 0; i < 12; i++ {
+		scores = append(scores, HighScore{Name: string(rune('a' + i)), Score: i % 4, Date: day.Add(time.Duration(i) * time.Hour)})
+	}
+	scores = rankHighScores(scores)
+
+	if len(scores) != maxHighScores {
+		t.Fatalf("%d scores kept, want %d", len(scores), maxHighScores)
+	}
+	names := ""
+	for _, s := range scores {
+		names += s.Name
+	}
+	if names != "dhlcgkbfja" {
+		t.Errorf("ranked %s, want dhlcgkbfja", names)
+	}
+}
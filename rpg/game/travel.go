@@ -0,0 +1,100 @@
+package game
+
+// travel is the walk the local player started by clicking a tile, taken one
+// step a turn for as long as the UI keeps sending Travel to goal. Clicking a
+// monster follows it and attacks it until it dies or gets out of sight.
+type travel struct {
+	goal    Pos
+	monster *Monster
+	next    Pos
+	// seen is the monsters in sight when the walk began. Any other coming
+	// into view stops it.
+	seen map[*Monster]bool
+}
+
+// target is where the player is walking to: the tile, or where the monster
+// they are after now is.
+func (t *travel) target() Pos {
+	if t.monster != nil {
+		return t.monster.Pos
+	}
+	return t.goal
+}
+
+// travelTo starts the walk to goal, or carries on with it, and reports whether
+// the player takes a step this turn. The walk stops without one when it is
+// over or there is no way there.
+func (gameStruct *Game) travelTo(goal Pos) bool {
+	level := gameStruct.CurrentLevel
+	t := gameStruct.travel
+	if t == nil || t.target() != goal {
+		if !inRange(level, goal) || !level.Map[goal.Y][goal.X].Seen {
+			gameStruct.travel = nil
+			return false
+		}
+		t = &travel{goal: goal, seen: level.monstersInSight()}
+		if monster, exists := level.Monsters[goal]; exists && level.Map[goal.Y][goal.X].Visible {
+			t.monster = monster
+		}
+		gameStruct.travel = t
+	} else {
+		for monster := range level.monstersInSight() {
+			if !t.seen[monster] {
+				level.AddEvent(level.Player.Name + " sees a " + monster.Name)
+				gameStruct.travel = nil
+				return false
+			}
+		}
+		if t.monster != nil && !level.Map[goal.Y][goal.X].Visible {
+			gameStruct.travel = nil
+			return false
+		}
+	}
+
+	path := level.astar(level.Player.Pos, goal)
+	if len(path) < 2 {
+		if level.Player.Pos != goal {
+			level.AddEvent("There is no way there")
+		}
+		gameStruct.travel = nil
+		return false
+	}
+	t.next = path[1]
+	return true
+}
+
+// travelled ends the walk once the player has got there, killed what they
+// were after, or left level, or if the step has them talking or trading.
+func (gameStruct *Game) travelled(level *Level) {
+	t := gameStruct.travel
+	switch {
+	case gameStruct.CurrentLevel != level, gameStruct.talk != nil, gameStruct.shopping != nil:
+	case t.monster != nil:
+		if t.monster.HP > 0 {
+			return
+		}
+	case t.next != t.goal:
+		return
+	}
+	gameStruct.travel = nil
+}
+
+// monstersInSight is the monsters on tiles the player can see.
+func (level *Level) monstersInSight() map[*Monster]bool {
+	seen := make(map[*Monster]bool)
+	for pos, monster := range level.Monsters {
+		if level.Map[pos.Y][pos.X].Visible {
+			seen[monster] = true
+		}
+	}
+	return seen
+}
+
+// travelGoal is where the player is walking to for the snapshot, or nil.
+func (gameStruct *Game) travelGoal() *Pos {
+	if gameStruct.travel == nil {
+		return nil
+	}
+	goal := gameStruct.travel.target()
+	return &goal
+}
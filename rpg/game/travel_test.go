@@ -0,0 +1,89 @@
+package game
+
+import "testing"
+
+// walkTo clicks goal and keeps sending Travel to where the snapshot says the
+// player is walking, the way the UI does, returning the turns it took.
+func (g *testGame) walkTo(goal Pos) int {
+	turn := g.Turn
+	for i := 0; i < 100; i++ {
+		g.Step(&Input{Type: Travel, Pos: goal})
+		next := g.travelGoal()
+		if next == nil {
+			return g.Turn - turn
+		}
+		goal = *next
+	}
+	g.t.Fatalf("still walking to %v after 100 turns", goal)
+	return 0
+}
+
+func TestTravel(t *testing.T) {
+	g := newTestGame(t, "a", map[string]string{"a": `
+		#######
+		#@....#
+		####..#
+		#.....#
+		#######
+	`})
+
+	// The player remembers what lies behind the wall.
+	g.CurrentLevel.Map[3][1].Seen = true
+	turns := g.walkTo(Pos{1, 3})
+	g.wantPlayerAt("a", Pos{1, 3})
+	if turns != 8 {
+		t.Errorf("walk took %d turns, want 8", turns)
+	}
+}
+
+func TestTravelStopsForMonster(t *testing.T) {
+	g := newTestGame(t, "a", map[string]string{"a": `
+		#######
+		#@....#
+		#######
+	`})
+
+	g.Step(&Input{Type: Travel, Pos: Pos{5, 1}})
+	g.wantPlayerAt("a", Pos{2, 1})
+	if !g.CurrentLevel.Map[1][5].Visible {
+		t.Fatalf("end of the corridor out of sight")
+	}
+	g.CurrentLevel.addMonster("Rat", Pos{5, 1})
+
+	g.Step(&Input{Type: Travel, Pos: Pos{5, 1}})
+	g.wantPlayerAt("a", Pos{2, 1})
+	g.wantEvent("Dralanor sees a Rat")
+	if g.travelGoal() != nil {
+		t.Errorf("still walking to %v", g.travelGoal())
+	}
+}
+
+func TestTravelAttacksMonster(t *testing.T) {
+	g := newTestGame(t, "a", map[string]string{"a": `
+		######
+		#@..S#
+		######
+	`})
+
+	// The spider comes to meet the player, who goes after it wherever it is.
+	g.walkTo(Pos{4, 1})
+	if len(g.CurrentLevel.Monsters) != 0 || len(g.CurrentLevel.Corpses) != 1 {
+		t.Errorf("spider not killed, monsters %v", g.CurrentLevel.Monsters)
+	}
+}
+
+func TestTravelCancelledByMove(t *testing.T) {
+	g := newTestGame(t, "a", map[string]string{"a": `
+		#######
+		#@....#
+		#######
+	`})
+
+	g.Step(&Input{Type: Travel, Pos: Pos{5, 1}})
+	g.wantPlayerAt("a", Pos{2, 1})
+	g.play(Left)
+	if g.travelGoal() != nil {
+		t.Errorf("still walking to %v after moving", g.travelGoal())
+	}
+	g.wantPlayerAt("a", Pos{1, 1})
+}
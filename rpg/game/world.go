@@ -0,0 +1,297 @@
+package game
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// World is the contents of world.json: which level the game starts on, what
+// each level is called and sounds like, and the portals between levels. The
+// format is described by maps/world.schema.json.
+//
+// Worlds written before world.json existed are a CSV file, world.txt, whose
+// first row is the start level and every other row a one-way portal:
+//
+//	fromLevel, x, y, toLevel, x, y
+//
+// It is still read when there is no world.json, but always written back as
+// JSON.
+type World struct {
+	Schema  string       `json:"$schema,omitempty"`
+	Start   string       `json:"start"`
+	Levels  []LevelInfo  `json:"levels,omitempty"`
+	Portals []PortalInfo `json:"portals,omitempty"`
+}
+
+// LevelInfo holds the metadata of the level in <Name>.map. Depth 0 means it
+// wasn't given, in which case it is the number of portals between the level
+// and the start level, plus one.
+type LevelInfo struct {
+	Name   string  `json:"name"`
+	Title  string  `json:"title,omitempty"`
+	Depth  int     `json:"depth,omitempty"`
+	Music  string  `json:"music,omitempty"`
+	Spawns []Spawn `json:"spawns,omitempty"`
+	// SpawnEvery is how many turns apart monsters from Spawns turn up out of
+	// sight, never if it is 0, while there are fewer than MaxMonsters on the
+	// level. Spawners turn them out in the open.
+	SpawnEvery  int       `json:"spawnEvery,omitempty"`
+	MaxMonsters int       `json:"maxMonsters,omitempty"`
+	Spawners    []Spawner `json:"spawners,omitempty"`
+	// Ambient is the light everywhere on the level, full daylight if not
+	// given. Lights are its fixed light sources.
+	Ambient *Light        `json:"ambient,omitempty"`
+	Lights  []LightSource `json:"lights,omitempty"`
+}
+
+// Spawn is one entry of a level's spawn table, Monster being the name of a
+// monster in monsterTypes. DepthWeight is added to Weight for every level of
+// depth below the first, so a negative one makes the monster rarer further
+// down. Up to Group of them turn up together.
+type Spawn struct {
+	Monster     string `json:"monster"`
+	Weight      int    `json:"weight"`
+	DepthWeight int    `json:"depthWeight,omitempty"`
+	Group       int    `json:"group,omitempty"`
+}
+
+// PortalInfo is a one-way portal. Requires, if set, is the name of an item the
+// player has to carry to use it.
+type PortalInfo struct {
+	From     PortalEnd `json:"from"`
+	To       PortalEnd `json:"to"`
+	Requires string    `json:"requires,omitempty"`
+
+	line int
+}
+
+type PortalEnd struct {
+	Level string `json:"level"`
+	X     int    `json:"x"`
+	Y     int    `json:"y"`
+}
+
+func (end PortalEnd) Pos() Pos {
+	return Pos{X: end.X, Y: end.Y}
+}
+
+const (
+	worldFile    = "world.json"
+	oldWorldFile = "world.txt"
+)
+
+// readWorld reads the world description in dir, preferring world.json over
+// world.txt, and returns it with the name of the file it came from.
+func readWorld(dir string) (*World, string, error) {
+	file, err := os.Open(filepath.Join(dir, worldFile))
+	if err == nil {
+		defer file.Close()
+		world := &World{}
+		err = json.NewDecoder(file).Decode(world)
+		if err != nil {
+			return nil, worldFile, err
+		}
+		if world.Start == "" {
+			return nil, worldFile, errors.New("world has no start level")
+		}
+		return world, worldFile, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, worldFile, err
+	}
+
+	file, err = os.Open(filepath.Join(dir, oldWorldFile))
+	if err != nil {
+		return nil, oldWorldFile, err
+	}
+	defer file.Close()
+	world, err := readWorldCSV(file)
+	return world, oldWorldFile, err
+}
+
+func readWorldCSV(r io.Reader) (*World, error) {
+	csvReader := csv.NewReader(r)
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+
+	world := &World{}
+	for {
+		row, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := csvReader.FieldPos(0)
+		if world.Start == "" {
+			world.Start = row[0]
+			continue
+		}
+		if len(row) != 6 {
+			return nil, fmt.Errorf("line %d: portal needs 6 columns, found %d", line, len(row))
+		}
+		coords := make([]int, 4)
+		for i, col := range []int{1, 2, 4, 5} {
+			n, err := strconv.ParseInt(row[col], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", line, err)
+			}
+			coords[i] = int(n)
+		}
+		world.Portals = append(world.Portals, PortalInfo{
+			From: PortalEnd{Level: row[0], X: coords[0], Y: coords[1]},
+			To:   PortalEnd{Level: row[3], X: coords[2], Y: coords[3]},
+			line: line,
+		})
+	}
+	if world.Start == "" {
+		return nil, errors.New("world file is empty")
+	}
+	return world, nil
+}
+
+func (world *World) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(world)
+}
+
+func (gameStruct *Game) loadWorldFile(dir string) {
+	world, file, err := readWorld(dir)
+	if err != nil {
+		panic(file + ": " + err.Error())
+	}
+
+	gameStruct.CurrentLevel = gameStruct.Levels[world.Start]
+	gameStruct.StartLevel = gameStruct.CurrentLevel
+	if gameStruct.CurrentLevel == nil {
+		fmt.Println("Couldn't find current level name in the world file")
+		panic(nil)
+	}
+
+	for _, info := range world.Levels {
+		level := gameStruct.Levels[info.Name]
+		if level == nil {
+			panic("World file describes level " + info.Name + " which has no map")
+		}
+		for _, spawn := range info.Spawns {
+			if monsterTypes[spawn.Monster] == nil {
+				panic("Unknown monster " + spawn.Monster + " in spawn table of " + info.Name)
+			}
+		}
+		for _, spawner := range info.Spawners {
+			if spawner.Monster != "" && monsterTypes[spawner.Monster] == nil {
+				panic("Unknown monster " + spawner.Monster + " in spawner of " + info.Name)
+			}
+		}
+		level.Title = info.Title
+		level.Depth = info.Depth
+		level.Music = info.Music
+		level.Spawns = info.Spawns
+		level.SpawnEvery = info.SpawnEvery
+		level.MaxMonsters = info.MaxMonsters
+		level.Spawners = info.Spawners
+		level.Lights = info.Lights
+		if info.Ambient != nil {
+			level.Ambient = *info.Ambient
+		}
+	}
+
+	for _, portal := range world.Portals {
+		levelWithPortal := gameStruct.Levels[portal.From.Level]
+		if levelWithPortal == nil {
+			fmt.Println("Couldn't find level name 1 in the world file")
+			panic(nil)
+		}
+		levelToTeleportTo := gameStruct.Levels[portal.To.Level]
+		if levelToTeleportTo == nil {
+			fmt.Println("Couldn't find level name 2 in the world file")
+			panic(nil)
+		}
+		levelWithPortal.Portals[portal.From.Pos()] = &LevelPos{Level: levelToTeleportTo, Pos: portal.To.Pos(), Requires: portal.Requires}
+	}
+
+	fillLevelDefaults(gameStruct.StartLevel)
+	for _, level := range gameStruct.Levels {
+		if level.Title == "" {
+			level.Title = level.Name
+		}
+	}
+}
+
+// fillLevelDefaults gives every level reachable from start without a depth
+// its distance from start through portals.
+func fillLevelDefaults(start *Level) {
+	depth := map[*Level]int{start: 1}
+	frontier := []*Level{start}
+	for len(frontier) > 0 {
+		current := frontier[0]
+		frontier = frontier[1:]
+		if current.Depth == 0 {
+			current.Depth = depth[current]
+		}
+		for _, dest := range current.Portals {
+			_, visited := depth[dest.Level]
+			if !visited {
+				depth[dest.Level] = depth[current] + 1
+				frontier = append(frontier, dest.Level)
+			}
+		}
+	}
+}
+
+// World describes the game's levels and portals as they are now, sorted so
+// that writing it twice gives the same file.
+func (gameStruct *Game) World() *World {
+	world := &World{Schema: "world.schema.json", Start: gameStruct.StartLevel.Name}
+
+	names := make([]string, 0, len(gameStruct.Levels))
+	for name := range gameStruct.Levels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		level := gameStruct.Levels[name]
+		info := LevelInfo{Name: name, Depth: level.Depth, Music: level.Music, Spawns: level.Spawns, Lights: level.Lights}
+		info.SpawnEvery = level.SpawnEvery
+		info.MaxMonsters = level.MaxMonsters
+		info.Spawners = level.Spawners
+		if level.Ambient != Daylight {
+			ambient := level.Ambient
+			info.Ambient = &ambient
+		}
+		if level.Title != name {
+			info.Title = level.Title
+		}
+		world.Levels = append(world.Levels, info)
+
+		positions := make([]Pos, 0, len(level.Portals))
+		for pos := range level.Portals {
+			positions = append(positions, pos)
+		}
+		sort.Slice(positions, func(i, j int) bool {
+			if positions[i].Y != positions[j].Y {
+				return positions[i].Y < positions[j].Y
+			}
+			return positions[i].X < positions[j].X
+		})
+		for _, pos := range positions {
+			dest := level.Portals[pos]
+			world.Portals = append(world.Portals, PortalInfo{
+				From:     PortalEnd{Level: name, X: pos.X, Y: pos.Y},
+				To:       PortalEnd{Level: dest.Level.Name, X: dest.X, Y: dest.Y},
+				Requires: dest.Requires,
+			})
+		}
+	}
+	return world
+}
@@ -0,0 +1,63 @@
+package game
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWorldFromCSV(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.map":     "#####\n#@.d#\n#####\n",
+		"b.map":     "####\n#u.#\n####\n",
+		"world.txt": "a\na, 3, 1, b, 1, 1\nb, 1, 1, a, 3, 1\n",
+	}
+	for name, contents := range files {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	old, file, err := readWorld(dir)
+	if err != nil || file != oldWorldFile {
+		t.Fatalf("read %s: %v, want world.txt", file, err)
+	}
+
+	g := newGame(1, 1, dir)
+	err = g.saveWorld()
+	if err != nil {
+		t.Fatal(err)
+	}
+	world, file, err := readWorld(dir)
+	if err != nil || file != worldFile {
+		t.Fatalf("read %s: %v, want world.json", file, err)
+	}
+
+	for i := range old.Portals {
+		old.Portals[i].line = 0
+	}
+	if world.Start != old.Start || !reflect.DeepEqual(world.Portals, old.Portals) {
+		t.Errorf("world.json starts on %s with portals %+v, want %s with %+v", world.Start, world.Portals, old.Start, old.Portals)
+	}
+	wantLevels := []LevelInfo{{Name: "a", Depth: 1}, {Name: "b", Depth: 2}}
+	if !reflect.DeepEqual(world.Levels, wantLevels) {
+		t.Errorf("levels %+v, want %+v", world.Levels, wantLevels)
+	}
+
+	// Written again, world.json comes out the same.
+	data, err := os.ReadFile(filepath.Join(dir, worldFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var again bytes.Buffer
+	err = newGame(1, 1, dir).World().Write(&again)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.String() != string(data) {
+		t.Errorf("world.json written again as\n%s\nwant\n%s", again.String(), data)
+	}
+}
@@ -1,17 +1,65 @@
 package main
 
 import (
+	"flag"
+	"fmt"
+	"os"
+
 	"github.com/LucasK1/gameswithgo/rpg/game"
+	"github.com/LucasK1/gameswithgo/rpg/server"
 	"github.com/LucasK1/gameswithgo/rpg/ui2d"
 )
 
 func main() {
-	game := game.NewGame(1)
+	serve := flag.String("serve", "", "host a multiplayer game on this address instead of playing")
+	connect := flag.String("connect", "", "join the multiplayer game hosted at this address")
+	name := flag.String("name", "Dralanor", "player name when joining a multiplayer game")
+	flag.Parse()
+
+	switch {
+	case *serve != "":
+		err := server.New(game.NewGame(0)).ListenAndServe(*serve)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+
+	case *connect != "":
+		client, err := server.Dial(*connect, *name)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		go func() {
+			ui := ui2d.NewUI(client.InputChan, client.LevelChan)
+			ui.Run()
+		}()
+		err = client.Run()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
 
-	go func() {
-		ui := ui2d.NewUI(game.InputChan, game.LevelChans[0])
-		ui.Run()
-	}()
-	game.Run()
+	default:
+		game := game.NewGame(1)
+
+		go func() {
+			ui := ui2d.NewUI(game.InputChan, game.LevelChans[0])
+			ui.ShowMenu()
+			ui.Run()
+		}()
+		game.Run()
+		if game.Stats.Died {
+			fmt.Println("Killed by", game.Stats.KilledBy, "after", game.Turn, "turns")
+			printHighScores()
+		}
+	}
+}
 
+func printHighScores() {
+	scores, err := game.HighScores()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+	for i, s := range scores {
+		fmt.Printf("%2d. %6d  %s, %s on depth %d\n", i+1, s.Score, s.Name, s.Cause, s.Deepest)
+	}
 }
@@ -0,0 +1,252 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/LucasK1/gameswithgo/rpg/game"
+)
+
+var ErrDied = errors.New("player died")
+
+// Client plays on a Server. A UI drives it exactly like a local Game, by
+// sending on InputChan and drawing what arrives on LevelChan.
+type Client struct {
+	InputChan chan *game.Input
+	LevelChan chan *game.Snapshot
+	ID        int
+
+	conn     net.Conn
+	enc      *json.Encoder
+	dec      *json.Decoder
+	level    string
+	memory   [][]game.Tile
+	player   game.Player
+	visible  []EntityInfo
+	events   []string
+	eventPos int
+	happened []game.TurnEvent
+}
+
+func Dial(addr, name string) (*Client, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Client{conn: conn}
+	c.enc = json.NewEncoder(conn)
+	c.dec = json.NewDecoder(conn)
+	c.InputChan = make(chan *game.Input)
+	c.LevelChan = make(chan *game.Snapshot)
+	c.events = make([]string, 10)
+
+	err = c.enc.Encode(Message{Type: MsgHello, Name: name})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	var welcome Message
+	err = c.dec.Decode(&welcome)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if welcome.Type != MsgWelcome {
+		conn.Close()
+		return nil, errors.New("server did not welcome us: " + welcome.Type)
+	}
+	c.ID = welcome.ID
+
+	return c, nil
+}
+
+// Run forwards inputs to the server and snapshots to the UI until the player
+// quits, the window is closed or the connection fails.
+func (c *Client) Run() error {
+	errs := make(chan error, 1)
+	go func() {
+		for {
+			var msg Message
+			err := c.dec.Decode(&msg)
+			if err != nil {
+				errs <- err
+				return
+			}
+			switch msg.Type {
+			case MsgState:
+				err := c.apply(msg.State)
+				if err != nil {
+					errs <- err
+					return
+				}
+				c.LevelChan <- c.snapshot()
+			case MsgDied:
+				errs <- ErrDied
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case input := <-c.InputChan:
+			switch input.Type {
+			case game.QuitGame:
+				c.conn.Close()
+				return nil
+			case game.CloseWindow:
+				c.conn.Close()
+				<-errs
+				close(c.LevelChan)
+				return nil
+			default:
+				name, exists := inputNames[input.Type]
+				if exists {
+					err := c.enc.Encode(Message{Type: MsgInput, Input: name})
+					if err != nil {
+						return err
+					}
+				}
+			}
+		case err := <-errs:
+			c.conn.Close()
+			return err
+		}
+	}
+}
+
+// apply takes in a state from the server. One that doesn't fit its own map is
+// refused before anything is changed, as the UI would crash drawing it.
+func (c *Client) apply(st *State) error {
+	err := st.check()
+	if err != nil {
+		return err
+	}
+	if st.Reset || len(c.memory) != st.Height || len(c.memory[0]) != st.Width {
+		c.memory = make([][]game.Tile, st.Height)
+		for y := range c.memory {
+			c.memory[y] = make([]game.Tile, st.Width)
+		}
+	}
+
+	c.level = st.Level
+	for y, row := range c.memory {
+		for x := range row {
+			c.memory[y][x].Visible = false
+		}
+	}
+	for _, t := range st.Tiles {
+		c.memory[t.Y][t.X].Rune = t.Rune
+		c.memory[t.Y][t.X].OverlayRune = t.Overlay
+		c.memory[t.Y][t.X].Seen = true
+	}
+	for i, pos := range st.Visible {
+		c.memory[pos.Y][pos.X].Visible = true
+		if i < len(st.Light) {
+			c.memory[pos.Y][pos.X].Light = st.Light[i]
+		}
+	}
+
+	c.player = game.Player{}
+	c.player.ID = st.Player.ID
+	c.player.Name = st.Player.Name
+	c.player.Rune = st.Player.Rune
+	c.player.Pos = game.Pos{X: st.Player.X, Y: st.Player.Y}
+	c.player.HP = st.Player.HP
+	c.player.Gold = st.Player.Gold
+
+	c.visible = st.Entities
+
+	c.happened = c.happened[:0]
+	for _, e := range st.Happened {
+		t, known := eventFromName(e.Type)
+		if known {
+			c.happened = append(c.happened, game.TurnEvent{Type: t, Actor: e.Actor, ActorID: e.ActorID, Target: e.Target, TargetID: e.TargetID, Pos: game.Pos{X: e.X, Y: e.Y}, Damage: e.Damage})
+		}
+	}
+
+	for _, event := range st.Events {
+		c.events[c.eventPos] = event
+		c.eventPos = (c.eventPos + 1) % len(c.events)
+	}
+	return nil
+}
+
+// check makes sure everything in the state is on its map.
+func (st *State) check() error {
+	if st == nil || st.Width <= 0 || st.Height <= 0 {
+		return errors.New("state without a map")
+	}
+	onMap := func(what string, x, y int) error {
+		if x < 0 || y < 0 || x >= st.Width || y >= st.Height {
+			return fmt.Errorf("%s at %d, %d is off the %dx%d map", what, x, y, st.Width, st.Height)
+		}
+		return nil
+	}
+	for _, t := range st.Tiles {
+		err := onMap("tile", t.X, t.Y)
+		if err != nil {
+			return err
+		}
+	}
+	for _, pos := range st.Visible {
+		err := onMap("visible tile", pos.X, pos.Y)
+		if err != nil {
+			return err
+		}
+	}
+	for _, e := range st.Entities {
+		err := onMap(e.Kind, e.X, e.Y)
+		if err != nil {
+			return err
+		}
+	}
+	return onMap("player", st.Player.X, st.Player.Y)
+}
+
+func (c *Client) snapshot() *game.Snapshot {
+	snap := &game.Snapshot{Name: c.level}
+	snap.Map = make([][]game.Tile, len(c.memory))
+	for y, row := range c.memory {
+		snap.Map[y] = make([]game.Tile, len(row))
+		copy(snap.Map[y], row)
+	}
+	snap.Player = c.player
+
+	snap.Monsters = make(map[game.Pos]*game.Monster)
+	snap.NPCs = make(map[game.Pos]*game.NPC)
+	snap.Others = make(map[game.Pos]*game.Player)
+	snap.Corpses = make(map[game.Pos]*game.Corpse)
+	snap.Loot = make(map[game.Pos]*game.Loot)
+	for _, e := range c.visible {
+		character := game.Character{}
+		character.ID = e.ID
+		character.Name = e.Name
+		character.Rune = e.Rune
+		character.Pos = game.Pos{X: e.X, Y: e.Y}
+		character.HP = e.HP
+		switch e.Kind {
+		case KindMonster:
+			snap.Monsters[character.Pos] = &game.Monster{Character: character}
+		case KindPlayer:
+			snap.Others[character.Pos] = &game.Player{Character: character}
+		case KindNPC:
+			snap.NPCs[character.Pos] = &game.NPC{Character: character}
+		case KindCorpse:
+			snap.Corpses[character.Pos] = &game.Corpse{Monster: e.Name, Rune: e.Rune}
+		case KindLoot:
+			snap.Loot[character.Pos] = &game.Loot{Gold: e.Gold}
+		}
+	}
+
+	snap.Events = make([]string, len(c.events))
+	copy(snap.Events, c.events)
+	snap.EventPos = c.eventPos
+	snap.TurnEvents = make([]game.TurnEvent, len(c.happened))
+	copy(snap.TurnEvents, c.happened)
+	return snap
+}
@@ -0,0 +1,147 @@
+// Package server hosts an rpg Game for several players over TCP and provides
+// the matching client, which looks to a UI exactly like a local Game: it has
+// an InputChan to send to and a LevelChan of snapshots to draw.
+//
+// # Wire protocol
+//
+// A connection carries newline-delimited JSON, one Message per line, in both
+// directions. The client opens with
+//
+//	{"type":"hello","name":"Dralanor"}
+//
+// and the server answers with the id of the player it created
+//
+//	{"type":"welcome","id":1}
+//
+// followed by a "state" message. From then on the client sends one message
+// per key press
+//
+//	{"type":"input","input":"up"}
+//
+// where input is one of "up", "down", "left", "right" or "search", and after
+// every turn played by anyone the server sends each client a "state" message
+// describing what that client's player can see. States are deltas: tiles are
+// only sent when they come into view for the first time or change from what
+// the client was last told, so the client keeps its own memory of the map.
+// Visible, Entities and Player are always complete, and Light is the light on
+// each visible tile, in the same order. Happened lists the turn events, such
+// as attacks and doors opening, that the player saw during the turn, with type
+// one of "move", "door", "attack", "hit", "portal" or "death". Their actorId
+// and targetId are the ids of the entities involved, or -1 for none, as names
+// aren't unique: the player's own id is the one it was welcomed with, and 0 is
+// the player at the server. Entities are of kind "player", "monster", "npc",
+// "corpse" or "loot"; NPCs only talk to the local player, so to remote players
+// they are just in the way. A corpse has the name and rune of the monster it
+// was, and loot the gold lying there. When Reset is set the client must forget
+// its map, which happens on the first state and whenever the player changes
+// level. When the player is killed the server sends
+//
+//	{"type":"died"}
+//
+// and closes the connection. Otherwise either side ends the session by
+// closing the connection.
+package server
+
+import "github.com/LucasK1/gameswithgo/rpg/game"
+
+type Message struct {
+	Type  string `json:"type"`
+	Name  string `json:"name,omitempty"`
+	ID    int    `json:"id,omitempty"`
+	Input string `json:"input,omitempty"`
+	State *State `json:"state,omitempty"`
+}
+
+type State struct {
+	Level    string       `json:"level"`
+	Width    int          `json:"width"`
+	Height   int          `json:"height"`
+	Reset    bool         `json:"reset,omitempty"`
+	Tiles    []TileInfo   `json:"tiles,omitempty"`
+	Visible  []game.Pos   `json:"visible"`
+	Light    []game.Light `json:"light,omitempty"`
+	Player   EntityInfo   `json:"player"`
+	Entities []EntityInfo `json:"entities,omitempty"`
+	Events   []string     `json:"events,omitempty"`
+	Happened []EventInfo  `json:"happened,omitempty"`
+}
+
+type TileInfo struct {
+	X       int  `json:"x"`
+	Y       int  `json:"y"`
+	Rune    rune `json:"rune"`
+	Overlay rune `json:"overlay,omitempty"`
+}
+
+type EntityInfo struct {
+	ID   int    `json:"id,omitempty"`
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	Rune rune   `json:"rune"`
+	X    int    `json:"x"`
+	Y    int    `json:"y"`
+	HP   int    `json:"hp"`
+	Gold int    `json:"gold,omitempty"`
+}
+
+type EventInfo struct {
+	Type     string `json:"type"`
+	Actor    string `json:"actor,omitempty"`
+	ActorID  int    `json:"actorId"`
+	Target   string `json:"target,omitempty"`
+	TargetID int    `json:"targetId"`
+	X        int    `json:"x"`
+	Y        int    `json:"y"`
+	Damage   int    `json:"damage,omitempty"`
+}
+
+const (
+	MsgHello   = "hello"
+	MsgWelcome = "welcome"
+	MsgInput   = "input"
+	MsgState   = "state"
+	MsgDied    = "died"
+)
+
+const (
+	KindPlayer  = "player"
+	KindMonster = "monster"
+	KindNPC     = "npc"
+	KindCorpse  = "corpse"
+	KindLoot    = "loot"
+)
+
+var inputNames = map[game.InputType]string{
+	game.Up:     "up",
+	game.Down:   "down",
+	game.Left:   "left",
+	game.Right:  "right",
+	game.Search: "search",
+}
+
+func inputFromName(name string) game.InputType {
+	for t, n := range inputNames {
+		if n == name {
+			return t
+		}
+	}
+	return game.None
+}
+
+var eventNames = map[game.GameEvent]string{
+	game.Move:     "move",
+	game.DoorOpen: "door",
+	game.Attack:   "attack",
+	game.Hit:      "hit",
+	game.Portal:   "portal",
+	game.Death:    "death",
+}
+
+func eventFromName(name string) (game.GameEvent, bool) {
+	for t, n := range eventNames {
+		if n == name {
+			return t, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,233 @@
+package server
+
+import (
+	"encoding/json"
+	"net"
+
+	"github.com/LucasK1/gameswithgo/rpg/game"
+)
+
+type client struct {
+	conn     net.Conn
+	name     string
+	id       int
+	out      chan Message
+	level    *game.Level
+	known    map[game.Pos]game.Tile
+	eventPos int
+}
+
+type clientInput struct {
+	client *client
+	input  game.InputType
+}
+
+// Server owns a Game and plays it for every connected client. All access to
+// the game happens on the goroutine running loop; connection goroutines only
+// talk to it over channels.
+type Server struct {
+	game    *game.Game
+	clients map[int]*client
+	joins   chan *client
+	leaves  chan *client
+	inputs  chan clientInput
+}
+
+func New(g *game.Game) *Server {
+	s := &Server{game: g}
+	s.clients = make(map[int]*client)
+	s.joins = make(chan *client)
+	s.leaves = make(chan *client)
+	s.inputs = make(chan clientInput)
+	return s
+}
+
+func (s *Server) ListenAndServe(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(listener)
+}
+
+// Serve accepts connections on listener until it fails and returns that error.
+func (s *Server) Serve(listener net.Listener) error {
+	go s.loop()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	dec := json.NewDecoder(conn)
+
+	var hello Message
+	err := dec.Decode(&hello)
+	if err != nil || hello.Type != MsgHello {
+		conn.Close()
+		return
+	}
+
+	c := &client{conn: conn, name: hello.Name, out: make(chan Message, 64)}
+	go c.write()
+	s.joins <- c
+
+	for {
+		var msg Message
+		err := dec.Decode(&msg)
+		if err != nil {
+			break
+		}
+		if msg.Type == MsgInput {
+			input := inputFromName(msg.Input)
+			if input != game.None {
+				s.inputs <- clientInput{client: c, input: input}
+			}
+		}
+	}
+	s.leaves <- c
+}
+
+func (c *client) write() {
+	enc := json.NewEncoder(c.conn)
+	for msg := range c.out {
+		err := enc.Encode(msg)
+		if err != nil {
+			break
+		}
+	}
+	c.conn.Close()
+}
+
+// send queues msg for c. A client too slow to keep up with the game is
+// disconnected rather than allowed to stall everyone else.
+func (c *client) send(msg Message) {
+	select {
+	case c.out <- msg:
+	default:
+		c.conn.Close()
+	}
+}
+
+func (s *Server) loop() {
+	for {
+		select {
+		case c := <-s.joins:
+			c.id = s.game.AddPlayer(c.name).ID
+			s.clients[c.id] = c
+			c.send(Message{Type: MsgWelcome, ID: c.id})
+
+		case c := <-s.leaves:
+			if s.clients[c.id] != c {
+				continue
+			}
+			s.drop(c)
+			s.game.RemovePlayer(c.id)
+
+		case in := <-s.inputs:
+			if s.clients[in.client.id] != in.client {
+				continue
+			}
+			s.game.Step(&game.Input{Type: in.input, PlayerID: in.client.id})
+		}
+		s.broadcast()
+	}
+}
+
+func (s *Server) drop(c *client) {
+	delete(s.clients, c.id)
+	close(c.out)
+}
+
+func (s *Server) broadcast() {
+	for id, c := range s.clients {
+		remote := s.game.Remotes[id]
+		if remote == nil {
+			c.send(Message{Type: MsgDied})
+			s.drop(c)
+			continue
+		}
+		c.send(Message{Type: MsgState, State: s.state(c, remote)})
+	}
+}
+
+// state works out what c has to be told about the world as remote sees it now.
+func (s *Server) state(c *client, remote *game.RemotePlayer) *State {
+	level := remote.Level
+	st := &State{Level: level.Name, Width: len(level.Map[0]), Height: len(level.Map)}
+
+	if c.level != level {
+		st.Reset = true
+		c.level = level
+		c.known = make(map[game.Pos]game.Tile)
+		c.eventPos = level.EventPos
+	}
+
+	visible := level.FieldOfView(&remote.Character)
+	st.Visible = make([]game.Pos, 0, len(visible))
+	st.Light = make([]game.Light, 0, len(visible))
+	for pos := range visible {
+		tile := level.Map[pos.Y][pos.X]
+		st.Visible = append(st.Visible, pos)
+		st.Light = append(st.Light, tile.Light)
+
+		known, seen := c.known[pos]
+		if !seen || known.Rune != tile.Rune || known.OverlayRune != tile.OverlayRune {
+			c.known[pos] = tile
+			st.Tiles = append(st.Tiles, TileInfo{X: pos.X, Y: pos.Y, Rune: tile.Rune, Overlay: tile.OverlayRune})
+		}
+
+		monster, exists := level.Monsters[pos]
+		if exists {
+			st.Entities = append(st.Entities, entity(KindMonster, monster.ID, &monster.Character))
+		}
+		npc, exists := level.NPCs[pos]
+		if exists {
+			st.Entities = append(st.Entities, entity(KindNPC, 0, &npc.Character))
+		}
+		corpse, exists := level.Corpses[pos]
+		if exists {
+			st.Entities = append(st.Entities, EntityInfo{Kind: KindCorpse, Name: corpse.Monster, Rune: corpse.Rune, X: pos.X, Y: pos.Y})
+		}
+		loot, exists := level.Loot[pos]
+		if exists {
+			st.Entities = append(st.Entities, EntityInfo{Kind: KindLoot, Name: "loot", X: pos.X, Y: pos.Y, Gold: loot.Gold})
+		}
+	}
+
+	for _, other := range s.game.RemotesOn(level) {
+		if other != remote && visible[other.Pos] {
+			st.Entities = append(st.Entities, entity(KindPlayer, other.ID, &other.Character))
+		}
+	}
+	if s.game.Local && level == s.game.CurrentLevel && visible[level.Player.Pos] {
+		st.Entities = append(st.Entities, entity(KindPlayer, 0, &level.Player.Character))
+	}
+
+	st.Player = entity(KindPlayer, remote.ID, &remote.Character)
+	st.Player.Gold = remote.Gold
+
+	for _, event := range level.TurnEvents {
+		if visible[event.Pos] {
+			st.Happened = append(st.Happened, EventInfo{Type: eventNames[event.Type], Actor: event.Actor, ActorID: event.ActorID, Target: event.Target, TargetID: event.TargetID, X: event.Pos.X, Y: event.Pos.Y, Damage: event.Damage})
+		}
+	}
+
+	for c.eventPos != level.EventPos {
+		event := level.Events[c.eventPos]
+		if event != "" {
+			st.Events = append(st.Events, event)
+		}
+		c.eventPos = (c.eventPos + 1) % len(level.Events)
+	}
+
+	return st
+}
+
+func entity(kind string, id int, c *game.Character) EntityInfo {
+	return EntityInfo{ID: id, Kind: kind, Name: c.Name, Rune: c.Rune, X: c.X, Y: c.Y, HP: c.HP}
+}
@@ -0,0 +1,216 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/LucasK1/gameswithgo/rpg/game"
+)
+
+// serve plays a game on a server for remote players only, listening on a
+// loopback port, and returns the game and the server's address.
+func serve(t *testing.T, m string) (*game.Game, string) {
+	t.Helper()
+	return serveLevels(t, map[string]string{"a": m})
+}
+
+// serveLevels is serve for a game on several levels, starting on a.
+func serveLevels(t *testing.T, maps map[string]string) (*game.Game, string) {
+	t.Helper()
+	g, err := game.NewGameFromMaps(t.TempDir(), 0, 1, "a", maps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go New(g).Serve(listener)
+	return g, listener.Addr().String()
+}
+
+// hello connects to addr and says hello as name, without a Client, to see
+// the messages as they are sent.
+func hello(t *testing.T, addr, name string) (*json.Encoder, *json.Decoder) {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	enc := json.NewEncoder(conn)
+	err = enc.Encode(Message{Type: MsgHello, Name: name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return enc, json.NewDecoder(conn)
+}
+
+func receive(t *testing.T, dec *json.Decoder, msgType string) Message {
+	t.Helper()
+	var msg Message
+	err := dec.Decode(&msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Type != msgType {
+		t.Fatalf("got a %q message, want %q", msg.Type, msgType)
+	}
+	return msg
+}
+
+func TestHelloWelcome(t *testing.T) {
+	_, addr := serve(t, `
+		#####
+		#@..#
+		#####
+	`)
+	_, dec := hello(t, addr, "Ana")
+
+	welcome := receive(t, dec, MsgWelcome)
+	if welcome.ID <= 0 {
+		t.Errorf("welcomed with id %d, want one above 0", welcome.ID)
+	}
+	st := receive(t, dec, MsgState).State
+	if !st.Reset || st.Level != "a" || st.Width != 5 || st.Height != 3 {
+		t.Errorf("first state %+v, want a reset 5x3 level a", st)
+	}
+	if st.Player.ID != welcome.ID || st.Player.Name != "Ana" || st.Player.X != 1 || st.Player.Y != 1 {
+		t.Errorf("player %+v, want Ana at 1,1", st.Player)
+	}
+}
+
+func TestInputPlaysTurn(t *testing.T) {
+	_, addr := serve(t, `
+		#####
+		#@..#
+		#####
+	`)
+	c, err := Dial(addr, "Ana")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go c.Run()
+	snap := <-c.LevelChan
+
+	c.InputChan <- &game.Input{Type: game.Right}
+	snap = <-c.LevelChan
+	if snap.Player.Pos != (game.Pos{X: 2, Y: 1}) {
+		t.Errorf("player at %v, want 2,1", snap.Player.Pos)
+	}
+	if len(snap.TurnEvents) != 1 || snap.TurnEvents[0].Type != game.Move || snap.TurnEvents[0].ActorID != c.ID {
+		t.Errorf("turn events %+v, want the player's move", snap.TurnEvents)
+	}
+	c.InputChan <- &game.Input{Type: game.QuitGame}
+}
+
+func TestClientFollowsPortal(t *testing.T) {
+	g, addr := serveLevels(t, map[string]string{
+		"a": `
+			####
+			#@.#
+			####
+		`,
+		"b": `
+			#####
+			#...#
+			#####
+		`,
+	})
+	g.Levels["a"].Portals[game.Pos{X: 2, Y: 1}] = &game.LevelPos{Level: g.Levels["b"], Pos: game.Pos{X: 3, Y: 1}}
+	c, err := Dial(addr, "Ana")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go c.Run()
+	snap := <-c.LevelChan
+	if snap.Name != "a" {
+		t.Errorf("first snapshot of %q, want a", snap.Name)
+	}
+
+	c.InputChan <- &game.Input{Type: game.Right}
+	snap = <-c.LevelChan
+	if snap.Name != "b" || len(snap.Map[0]) != 5 || snap.Player.Pos != (game.Pos{X: 3, Y: 1}) {
+		t.Errorf("snapshot of %q %dx%d with the player at %v, want b 5x3 at 3,1", snap.Name, len(snap.Map[0]), len(snap.Map), snap.Player.Pos)
+	}
+	c.InputChan <- &game.Input{Type: game.QuitGame}
+}
+
+func TestStateShowsOnlyWhatPlayerSees(t *testing.T) {
+	_, addr := serve(t, `
+		##########
+		#@.R#...S#
+		##########
+	`)
+	_, dec := hello(t, addr, "Ana")
+	receive(t, dec, MsgWelcome)
+	st := receive(t, dec, MsgState).State
+
+	for _, pos := range st.Visible {
+		if pos.X > 4 {
+			t.Errorf("%v beyond the wall is visible", pos)
+		}
+	}
+	for _, tile := range st.Tiles {
+		if tile.X > 4 {
+			t.Errorf("tile %+v beyond the wall was sent", tile)
+		}
+	}
+	if len(st.Entities) != 1 || st.Entities[0].Name != "Rat" || st.Entities[0].Kind != KindMonster {
+		t.Errorf("entities %+v, want only the rat", st.Entities)
+	}
+}
+
+func TestDied(t *testing.T) {
+	g, addr := serve(t, `
+		####
+		#@S#
+		####
+	`)
+	g.CurrentLevel.Monsters[game.Pos{X: 2, Y: 1}].Strength = 100
+	c, err := Dial(addr, "Ana")
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs := make(chan error)
+	go func() {
+		errs <- c.Run()
+	}()
+	<-c.LevelChan
+
+	c.InputChan <- &game.Input{Type: game.Search}
+	err = <-errs
+	if !errors.Is(err, ErrDied) {
+		t.Errorf("Run returned %v, want ErrDied", err)
+	}
+}
+
+func TestClientRefusesStateOffMap(t *testing.T) {
+	c := &Client{events: make([]string, 10)}
+	good := &State{Width: 2, Height: 2, Tiles: []TileInfo{{X: 1, Y: 1, Rune: '.'}}, Visible: []game.Pos{{X: 1, Y: 1}}, Player: EntityInfo{X: 1, Y: 1}}
+	err := c.apply(good)
+	if err != nil {
+		t.Fatalf("good state refused: %v", err)
+	}
+
+	bad := []*State{
+		nil,
+		{Width: 0, Height: 2},
+		{Width: 2, Height: 2, Tiles: []TileInfo{{X: 2, Y: 0}}},
+		{Width: 2, Height: 2, Visible: []game.Pos{{X: 0, Y: -1}}},
+		{Width: 2, Height: 2, Entities: []EntityInfo{{Kind: KindMonster, X: 5, Y: 5}}},
+		{Width: 2, Height: 2, Player: EntityInfo{X: 3, Y: 0}},
+	}
+	for _, st := range bad {
+		err := c.apply(st)
+		if err == nil {
+			t.Errorf("state %+v accepted", st)
+		}
+	}
+	if c.memory[1][1].Rune != '.' || c.player.Pos != (game.Pos{X: 1, Y: 1}) {
+		t.Errorf("bad states changed what the client knew")
+	}
+}
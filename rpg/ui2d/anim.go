@@ -0,0 +1,191 @@
+package ui2d
+
+import (
+	"math"
+
+	"github.com/LucasK1/gameswithgo/rpg/game"
+)
+
+// Animation timings in milliseconds.
+const (
+	moveTime  = 120
+	lungeTime = 160
+	flashTime = 200
+)
+
+// tween slides something from one tile to another, starting at start. A
+// lunge only goes halfway and comes back again.
+type tween struct {
+	from, to game.Pos
+	start    uint32
+	duration uint32
+	lunge    bool
+}
+
+func (t *tween) active(now uint32) bool {
+	return t.duration > 0 && now-t.start < t.duration
+}
+
+// rest is where the tweened thing really is.
+func (t *tween) rest() game.Pos {
+	if t.lunge {
+		return t.from
+	}
+	return t.to
+}
+
+// at is the position, in tiles, to draw at.
+func (t *tween) at(now uint32) (float64, float64) {
+	if !t.active(now) {
+		pos := t.rest()
+		return float64(pos.X), float64(pos.Y)
+	}
+	k := float64(now-t.start) / float64(t.duration)
+	if t.lunge {
+		k = 0.5 - math.Abs(k-0.5)
+	}
+	return float64(t.from.X) + float64(t.to.X-t.from.X)*k, float64(t.from.Y) + float64(t.to.Y-t.from.Y)*k
+}
+
+// animator works out how entities move between two snapshots and plays that
+// out over real time, independently of how often the game takes a turn. The
+// clock is set once per frame from SDL's ticks.
+type animator struct {
+	now         uint32
+	player      tween
+	playerFlash uint32
+	monsters    map[int]*tween
+	flashes     map[int]uint32
+	wasBusy     bool
+	lastFrame   uint32
+}
+
+func (a *animator) tick(now uint32) {
+	a.now = now
+}
+
+// observe starts the animations for the change from prev to next. Moves are
+// worked out from where things were and are, attacks and hits from the turn
+// events.
+func (a *animator) observe(prev, next *game.Snapshot) {
+	a.monsters = make(map[int]*tween)
+	a.flashes = make(map[int]uint32)
+	a.player = tween{}
+	a.playerFlash = 0
+	if prev == nil || prev.Name != next.Name {
+		return
+	}
+
+	from := prev.Player.Pos
+	to := next.Player.Pos
+	if from != to && within(from, to, 1) {
+		a.player = tween{from: from, to: to, start: a.now, duration: moveTime}
+	}
+
+	for _, event := range next.TurnEvents {
+		switch event.Type {
+		case game.Attack:
+			if event.ActorID == next.Player.ID && from == to && within(to, event.Pos, 1) {
+				a.player = tween{from: to, to: event.Pos, start: a.now, duration: lungeTime, lunge: true}
+			}
+		case game.Hit:
+			monster, exists := next.Monsters[event.Pos]
+			if event.TargetID == next.Player.ID {
+				a.playerFlash = a.now
+			} else if exists && monster.ID == event.TargetID {
+				a.flashes[monster.ID] = a.now
+			}
+		}
+	}
+
+	before := make(map[int]*game.Monster)
+	for _, monster := range prev.Monsters {
+		if monster.ID != 0 {
+			before[monster.ID] = monster
+		}
+	}
+	for _, monster := range next.Monsters {
+		old, exists := before[monster.ID]
+		if monster.ID == 0 || !exists {
+			continue
+		}
+		if old.Pos != monster.Pos && within(old.Pos, monster.Pos, int(old.Speed+1)) {
+			a.monsters[monster.ID] = &tween{from: old.Pos, to: monster.Pos, start: a.now, duration: moveTime}
+		}
+	}
+}
+
+// reset stops all animations, for when entities should be drawn where they
+// are, such as in the editor.
+func (a *animator) reset() {
+	a.player = tween{}
+	a.playerFlash = 0
+	a.monsters = nil
+	a.flashes = nil
+}
+
+func within(a, b game.Pos, dist int) bool {
+	dx := a.X - b.X
+	dy := a.Y - b.Y
+	return dx >= -dist && dx <= dist && dy >= -dist && dy <= dist
+}
+
+// playerAt is where to draw the player, who is at pos.
+func (a *animator) playerAt(pos game.Pos) (float64, float64) {
+	if a.player.duration == 0 || a.player.rest() != pos {
+		return float64(pos.X), float64(pos.Y)
+	}
+	return a.player.at(a.now)
+}
+
+func (a *animator) monsterAt(monster *game.Monster) (float64, float64) {
+	t, exists := a.monsters[monster.ID]
+	if !exists || t.rest() != monster.Pos {
+		return float64(monster.X), float64(monster.Y)
+	}
+	return t.at(a.now)
+}
+
+func (a *animator) flashing(start uint32) bool {
+	return start != 0 && a.now-start < flashTime
+}
+
+func (a *animator) playerFlashing() bool {
+	return a.flashing(a.playerFlash)
+}
+
+func (a *animator) monsterFlashing(monster *game.Monster) bool {
+	return a.flashing(a.flashes[monster.ID])
+}
+
+// needsRedraw reports whether the screen has to be drawn again this frame to
+// move an animation along. frameTime is how often animated sprites change
+// frame, or 0 if none are.
+func (a *animator) needsRedraw(frameTime uint32) bool {
+	busy := a.busy()
+	redraw := busy || a.wasBusy
+	a.wasBusy = busy
+	if frameTime != 0 && a.now/frameTime != a.lastFrame {
+		a.lastFrame = a.now / frameTime
+		redraw = true
+	}
+	return redraw
+}
+
+// busy reports whether anything is still moving or flashing.
+func (a *animator) busy() bool {
+	if a.player.active(a.now) || a.playerFlashing() {
+		return true
+	}
+	for _, t := range a.monsters {
+		if t.active(a.now) {
+			return true
+		}
+	}
+	for _, start := range a.flashes {
+		if a.flashing(start) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,309 @@
+package ui2d
+
+import (
+	"bufio"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/LucasK1/gameswithgo/rpg/game"
+	"github.com/veandco/go-sdl2/mix"
+)
+
+const audioFile = assetsDir + "audio.txt"
+
+// Sounds further than hearingRange tiles from the player aren't heard, and
+// sounds panRange tiles to one side play only from that side.
+const (
+	hearingRange = 20.0
+	panRange     = 10.0
+)
+
+// eventSound is what to play for a game event: a random one of the .ogg and
+// .wav asset files starting with prefix, at a volume out of mix.MAX_VOLUME.
+// Events with no files make no sound. The .wav files are made by
+// rpg/cmd/sfxgen.
+type eventSound struct {
+	prefix string
+	volume int
+}
+
+var eventSounds = map[game.GameEvent]eventSound{
+	game.Move:     {"footstep", 10},
+	game.DoorOpen: {"doorOpen_", 32},
+	game.Attack:   {"attack", 48},
+	game.Hit:      {"hit", 48},
+	game.Death:    {"death", 64},
+	game.Portal:   {"portal", 48},
+}
+
+// audioBackend is what actually makes the noise. sdlAudio plays through
+// SDL_mixer and nullAudio plays nothing, for when there is no audio device.
+type audioBackend interface {
+	// loadSound loads a sound effect and returns a handle to play it with.
+	loadSound(path string) (int, error)
+	// playSound plays a loaded sound at volume, out of mix.MAX_VOLUME, with
+	// left and right scaling each side out of 255.
+	playSound(sound int, volume int, left, right uint8)
+	// playMusic loops the music file until other music is played.
+	playMusic(path string) error
+	setMusicVolume(volume int)
+}
+
+type sdlAudio struct {
+	chunks []*mix.Chunk
+	music  *mix.Music
+}
+
+func newSDLAudio() (*sdlAudio, error) {
+	err := mix.OpenAudio(22050, mix.DEFAULT_FORMAT, 2, 4096)
+	if err != nil {
+		return nil, err
+	}
+	return &sdlAudio{}, nil
+}
+
+func (a *sdlAudio) loadSound(path string) (int, error) {
+	chunk, err := mix.LoadWAV(path)
+	if err != nil {
+		return 0, err
+	}
+	a.chunks = append(a.chunks, chunk)
+	return len(a.chunks) - 1, nil
+}
+
+func (a *sdlAudio) playSound(sound int, volume int, left, right uint8) {
+	chunk := a.chunks[sound]
+	chunk.Volume(volume)
+	channel, err := chunk.Play(-1, 0)
+	if err != nil {
+		// Every channel is busy; this sound gets dropped.
+		return
+	}
+	mix.SetPanning(channel, left, right)
+}
+
+func (a *sdlAudio) playMusic(path string) error {
+	music, err := mix.LoadMUS(path)
+	if err != nil {
+		return err
+	}
+	err = music.Play(-1)
+	if err != nil {
+		return err
+	}
+	if a.music != nil {
+		a.music.Free()
+	}
+	a.music = music
+	return nil
+}
+
+func (a *sdlAudio) setMusicVolume(volume int) {
+	mix.VolumeMusic(volume)
+}
+
+type nullAudio struct {
+	sounds int
+}
+
+func (a *nullAudio) loadSound(path string) (int, error) {
+	_, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	a.sounds++
+	return a.sounds - 1, nil
+}
+
+func (a *nullAudio) playSound(sound int, volume int, left, right uint8) {}
+
+func (a *nullAudio) playMusic(path string) error {
+	_, err := os.Stat(path)
+	return err
+}
+
+func (a *nullAudio) setMusicVolume(volume int) {}
+
+// audio plays the game's music and sound effects at the player's volume
+// settings, which are kept in audio.txt as lines of
+//
+//	master 100
+//	music 60
+//	effects 100
+//	mute false
+//
+// with volumes in percent.
+type audio struct {
+	backend   audioBackend
+	master    int
+	music     int
+	effects   int
+	mute      bool
+	sounds    map[game.GameEvent][]int
+	musicName string
+}
+
+func newAudio(backend audioBackend) *audio {
+	a := &audio{backend: backend, master: 100, music: 100, effects: 100}
+	a.load()
+
+	a.sounds = make(map[game.GameEvent][]int)
+	for event, es := range eventSounds {
+		files := make([]string, 0)
+		for _, ext := range []string{".ogg", ".wav"} {
+			matches, err := filepath.Glob(assetsDir + es.prefix + "*" + ext)
+			if err != nil {
+				panic(err)
+			}
+			files = append(files, matches...)
+		}
+		sort.Strings(files)
+		for _, file := range files {
+			sound, err := backend.loadSound(file)
+			if err != nil {
+				panic(err)
+			}
+			a.sounds[event] = append(a.sounds[event], sound)
+		}
+	}
+	a.applyMusicVolume()
+	return a
+}
+
+func (a *audio) load() {
+	infile, err := os.Open(audioFile)
+	if os.IsNotExist(err) {
+		return
+	}
+	if err != nil {
+		panic(err)
+	}
+	defer infile.Close()
+
+	scanner := bufio.NewScanner(infile)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			panic("Invalid audio setting: " + scanner.Text())
+		}
+		if fields[0] == "mute" {
+			a.mute = fields[1] == "true"
+			continue
+		}
+		volume, err := strconv.Atoi(fields[1])
+		if err != nil {
+			panic(err)
+		}
+		volume = clamp(volume, 0, 100)
+		switch fields[0] {
+		case "master":
+			a.master = volume
+		case "music":
+			a.music = volume
+		case "effects":
+			a.effects = volume
+		default:
+			panic("Unknown audio setting: " + fields[0])
+		}
+	}
+}
+
+func (a *audio) save() error {
+	outfile, err := os.Create(audioFile)
+	if err != nil {
+		return err
+	}
+	defer outfile.Close()
+
+	w := bufio.NewWriter(outfile)
+	w.WriteString("master " + strconv.Itoa(a.master) + "\n")
+	w.WriteString("music " + strconv.Itoa(a.music) + "\n")
+	w.WriteString("effects " + strconv.Itoa(a.effects) + "\n")
+	w.WriteString("mute " + strconv.FormatBool(a.mute) + "\n")
+	return w.Flush()
+}
+
+// scale turns volume, out of mix.MAX_VOLUME, into what to play it at after
+// the master volume, the channel's volume in percent and mute.
+func (a *audio) scale(volume, channel int) int {
+	if a.mute {
+		return 0
+	}
+	return volume * a.master * channel / 10000
+}
+
+func (a *audio) applyMusicVolume() {
+	a.backend.setMusicVolume(a.scale(mix.MAX_VOLUME, a.music))
+}
+
+func (a *audio) toggleMute() {
+	a.mute = !a.mute
+	a.applyMusicVolume()
+}
+
+// changeMaster changes the master volume by delta percent.
+func (a *audio) changeMaster(delta int) {
+	a.master = clamp(a.master+delta, 0, 100)
+	a.applyMusicVolume()
+}
+
+func (a *audio) changeMusic(delta int) {
+	a.music = clamp(a.music+delta, 0, 100)
+	a.applyMusicVolume()
+}
+
+func (a *audio) changeEffects(delta int) {
+	a.effects = clamp(a.effects+delta, 0, 100)
+}
+
+// playMusic loops the named music file from the assets until another is
+// played. Levels without music keep whatever is playing.
+func (a *audio) playMusic(name string) {
+	if name == "" || name == a.musicName {
+		return
+	}
+	err := a.backend.playMusic(assetsDir + name)
+	if err != nil {
+		panic(err)
+	}
+	a.musicName = name
+	a.applyMusicVolume()
+}
+
+// playEvent plays the sound for event happening at pos, quieter the further
+// it is from listener and panned to the side it is on.
+func (a *audio) playEvent(event game.GameEvent, pos, listener game.Pos) {
+	sounds := a.sounds[event]
+	if len(sounds) == 0 {
+		return
+	}
+
+	dx := float64(pos.X - listener.X)
+	dy := float64(pos.Y - listener.Y)
+	falloff := 1 - math.Sqrt(dx*dx+dy*dy)/hearingRange
+	if falloff <= 0 {
+		return
+	}
+	volume := int(float64(a.scale(eventSounds[event].volume, a.effects)) * falloff)
+	if volume == 0 {
+		return
+	}
+
+	pan := math.Max(-1, math.Min(1, dx/panRange))
+	left, right := uint8(255), uint8(255)
+	if pan > 0 {
+		left = uint8(255 * (1 - pan))
+	} else {
+		right = uint8(255 * (1 + pan))
+	}
+
+	a.backend.playSound(sounds[rand.Intn(len(sounds))], volume, left, right)
+}
@@ -0,0 +1,86 @@
+package ui2d
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/LucasK1/gameswithgo/rpg/game"
+)
+
+func TestNullAudio(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "beep.wav")
+	err := os.WriteFile(path, nil, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := &nullAudio{}
+	for want := 0; want < 2; want++ {
+		sound, err := a.loadSound(path)
+		if err != nil || sound != want {
+			t.Errorf("loaded sound %d, %v, want %d", sound, err, want)
+		}
+	}
+	_, err = a.loadSound(filepath.Join(dir, "missing.wav"))
+	if err == nil {
+		t.Errorf("loaded a missing sound")
+	}
+	if a.playMusic(path) != nil || a.playMusic(filepath.Join(dir, "missing.ogg")) == nil {
+		t.Errorf("music found where there is none, or not found where there is")
+	}
+}
+
+func TestEveryEventHasASound(t *testing.T) {
+	a := newAudio(&nullAudio{})
+	for event, es := range eventSounds {
+		if len(a.sounds[event]) == 0 {
+			t.Errorf("no %s* sound in the assets for event %v", es.prefix, event)
+		}
+	}
+}
+
+// playedSound is a sound playEvent played.
+type playedSound struct {
+	volume      int
+	left, right uint8
+}
+
+// recordingAudio plays nothing, but keeps what it was asked to play.
+type recordingAudio struct {
+	nullAudio
+	played []playedSound
+}
+
+func (a *recordingAudio) playSound(sound int, volume int, left, right uint8) {
+	a.played = append(a.played, playedSound{volume, left, right})
+}
+
+func TestPlayEvent(t *testing.T) {
+	backend := &recordingAudio{}
+	a := newAudio(backend)
+	a.master, a.effects, a.mute = 100, 100, false
+	listener := game.Pos{X: 20, Y: 20}
+
+	a.playEvent(game.Death, listener, listener)
+	a.playEvent(game.Death, game.Pos{X: 30, Y: 20}, listener)
+	a.playEvent(game.Death, game.Pos{X: 10, Y: 20}, listener)
+	a.playEvent(game.Death, game.Pos{X: 45, Y: 20}, listener)
+	a.mute = true
+	a.playEvent(game.Death, listener, listener)
+
+	want := []playedSound{
+		{eventSounds[game.Death].volume, 255, 255},
+		{eventSounds[game.Death].volume / 2, 0, 255},
+		{eventSounds[game.Death].volume / 2, 255, 0},
+	}
+	if len(backend.played) != len(want) {
+		t.Fatalf("played %+v, want %+v", backend.played, want)
+	}
+	for i := range want {
+		if backend.played[i] != want[i] {
+			t.Errorf("played %+v, want %+v", backend.played[i], want[i])
+		}
+	}
+}
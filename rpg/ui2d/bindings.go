@@ -0,0 +1,343 @@
+package ui2d
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/LucasK1/gameswithgo/rpg/game"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const bindingsFile = assetsDir + "bindings.txt"
+
+// Held direction keys repeat after repeatDelay, every repeatInterval, in
+// milliseconds.
+const (
+	repeatDelay    = 250
+	repeatInterval = 120
+)
+
+type action struct {
+	input   game.InputType
+	name    string
+	repeats bool
+}
+
+// actions lists everything that can be bound, in the order the rebinding
+// screen shows them.
+var actions = []action{
+	{game.Up, "up", true},
+	{game.Down, "down", true},
+	{game.Left, "left", true},
+	{game.Right, "right", true},
+	{game.Search, "search", false},
+}
+
+type binding struct {
+	keys    []sdl.Scancode
+	buttons []sdl.GameControllerButton
+}
+
+// bindings maps keys and game controller buttons to inputs. On disk it is a
+// text file with one binding per line,
+//
+//	up key Up
+//	up pad dpup
+//
+// giving the action, the device and the SDL name of the key or button.
+type bindings map[game.InputType]*binding
+
+func defaultBindings() bindings {
+	b := make(bindings)
+	b.addKey(game.Up, sdl.SCANCODE_UP)
+	b.addKey(game.Down, sdl.SCANCODE_DOWN)
+	b.addKey(game.Left, sdl.SCANCODE_LEFT)
+	b.addKey(game.Right, sdl.SCANCODE_RIGHT)
+	b.addButton(game.Up, sdl.CONTROLLER_BUTTON_DPAD_UP)
+	b.addButton(game.Down, sdl.CONTROLLER_BUTTON_DPAD_DOWN)
+	b.addButton(game.Left, sdl.CONTROLLER_BUTTON_DPAD_LEFT)
+	b.addButton(game.Right, sdl.CONTROLLER_BUTTON_DPAD_RIGHT)
+	return b
+}
+
+func (b bindings) get(input game.InputType) *binding {
+	bind, exists := b[input]
+	if !exists {
+		bind = &binding{}
+		b[input] = bind
+	}
+	return bind
+}
+
+func (b bindings) addKey(input game.InputType, key sdl.Scancode) {
+	bind := b.get(input)
+	for _, k := range bind.keys {
+		if k == key {
+			return
+		}
+	}
+	bind.keys = append(bind.keys, key)
+}
+
+func (b bindings) addButton(input game.InputType, button sdl.GameControllerButton) {
+	bind := b.get(input)
+	for _, btn := range bind.buttons {
+		if btn == button {
+			return
+		}
+	}
+	bind.buttons = append(bind.buttons, button)
+}
+
+// bindKey binds key to input alone, taking it from any other input.
+func (b bindings) bindKey(input game.InputType, key sdl.Scancode) {
+	for _, a := range actions {
+		bind := b.get(a.input)
+		for i, k := range bind.keys {
+			if k == key {
+				bind.keys = append(bind.keys[:i], bind.keys[i+1:]...)
+				break
+			}
+		}
+	}
+	b.addKey(input, key)
+}
+
+// bindButton binds button to input alone, taking it from any other input.
+func (b bindings) bindButton(input game.InputType, button sdl.GameControllerButton) {
+	for _, a := range actions {
+		bind := b.get(a.input)
+		for i, btn := range bind.buttons {
+			if btn == button {
+				bind.buttons = append(bind.buttons[:i], bind.buttons[i+1:]...)
+				break
+			}
+		}
+	}
+	b.addButton(input, button)
+}
+
+// loadBindings reads the bindings file, falling back to the defaults when
+// there isn't one. The file is the player's own, written when they rebind
+// something, and isn't part of the repository.
+func loadBindings() bindings {
+	infile, err := os.Open(bindingsFile)
+	if os.IsNotExist(err) {
+		return defaultBindings()
+	}
+	if err != nil {
+		panic(err)
+	}
+	defer infile.Close()
+
+	b, err := readBindings(infile)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// readBindings reads bindings in the format of the bindings file.
+func readBindings(r io.Reader) (bindings, error) {
+	b := make(bindings)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		fields := strings.SplitN(line, " ", 3)
+		if len(fields) != 3 {
+			return nil, errors.New("Invalid binding: " + line)
+		}
+
+		input := game.None
+		for _, a := range actions {
+			if a.name == fields[0] {
+				input = a.input
+			}
+		}
+		if input == game.None {
+			return nil, errors.New("Unknown action in bindings: " + fields[0])
+		}
+
+		name := strings.TrimSpace(fields[2])
+		switch fields[1] {
+		case "key":
+			key := sdl.GetScancodeFromName(name)
+			if key == sdl.SCANCODE_UNKNOWN {
+				return nil, errors.New("Unknown key in bindings: " + name)
+			}
+			b.addKey(input, key)
+		case "pad":
+			button := sdl.GameControllerGetButtonFromString(name)
+			if button == sdl.CONTROLLER_BUTTON_INVALID {
+				return nil, errors.New("Unknown controller button in bindings: " + name)
+			}
+			b.addButton(input, button)
+		default:
+			return nil, errors.New("Unknown device in bindings: " + fields[1])
+		}
+	}
+	return b, scanner.Err()
+}
+
+func (b bindings) save() error {
+	outfile, err := os.Create(bindingsFile)
+	if err != nil {
+		return err
+	}
+	defer outfile.Close()
+	return b.write(outfile)
+}
+
+// write writes the bindings in the format readBindings reads.
+func (b bindings) write(out io.Writer) error {
+	w := bufio.NewWriter(out)
+	for _, a := range actions {
+		bind := b.get(a.input)
+		for _, key := range bind.keys {
+			w.WriteString(a.name + " key " + sdl.GetScancodeName(key) + "\n")
+		}
+		for _, button := range bind.buttons {
+			w.WriteString(a.name + " pad " + sdl.GameControllerGetStringForButton(button) + "\n")
+		}
+	}
+	return w.Flush()
+}
+
+// describe lists the keys and buttons bound to input for the rebinding screen.
+func (b bindings) describe(input game.InputType) string {
+	bind := b.get(input)
+	names := make([]string, 0, len(bind.keys)+len(bind.buttons))
+	for _, key := range bind.keys {
+		names = append(names, sdl.GetScancodeName(key))
+	}
+	for _, button := range bind.buttons {
+		names = append(names, "pad "+sdl.GameControllerGetStringForButton(button))
+	}
+	if len(names) == 0 {
+		return "-"
+	}
+	return strings.Join(names, ", ")
+}
+
+type heldAction struct {
+	down      bool
+	since     uint32
+	lastFired uint32
+}
+
+func (ui *ui) openControllers() {
+	for i := 0; i < sdl.NumJoysticks(); i++ {
+		ui.openController(i)
+	}
+}
+
+func (ui *ui) openController(index int) {
+	if !sdl.IsGameController(index) {
+		return
+	}
+	controller := sdl.GameControllerOpen(index)
+	if controller != nil {
+		ui.controllers = append(ui.controllers, controller)
+	}
+}
+
+func (ui *ui) closeController(id sdl.JoystickID) {
+	for i, controller := range ui.controllers {
+		if controller.Joystick().InstanceID() == id {
+			controller.Close()
+			ui.controllers = append(ui.controllers[:i], ui.controllers[i+1:]...)
+			return
+		}
+	}
+}
+
+func (ui *ui) actionDown(input game.InputType) bool {
+	bind := ui.bindings.get(input)
+	for _, key := range bind.keys {
+		if ui.keyboardState[key] == 1 {
+			return true
+		}
+	}
+	for _, controller := range ui.controllers {
+		for _, button := range bind.buttons {
+			if controller.Button(button) == 1 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// pollInput returns the bound input that fired this frame, if any. An input
+// fires when its key or button goes down and, for directions, again while it
+// is held.
+func (ui *ui) pollInput() game.InputType {
+	now := sdl.GetTicks()
+	fired := game.None
+	for _, a := range actions {
+		held := ui.held[a.input]
+		if held == nil {
+			held = &heldAction{}
+			ui.held[a.input] = held
+		}
+
+		if !ui.actionDown(a.input) {
+			held.down = false
+			continue
+		}
+		if fired != game.None {
+			continue
+		}
+		if !held.down {
+			held.down = true
+			held.since = now
+			held.lastFired = now
+			fired = a.input
+		} else if a.repeats && now-held.since >= repeatDelay && now-held.lastFired >= repeatInterval {
+			held.lastFired = now
+			fired = a.input
+		}
+	}
+	return fired
+}
+
+// pressedKey returns the first key that went down this frame.
+func (ui *ui) pressedKey() (sdl.Scancode, bool) {
+	for i := range ui.keyboardState {
+		if ui.keyboardState[i] == 1 && ui.prevKeyboardState[i] == 0 {
+			return sdl.Scancode(i), true
+		}
+	}
+	return 0, false
+}
+
+// pressedButton returns the first controller button that went down this
+// frame.
+func (ui *ui) pressedButton() (sdl.GameControllerButton, bool) {
+	pressed := sdl.GameControllerButton(0)
+	found := false
+	for _, controller := range ui.controllers {
+		for button := sdl.GameControllerButton(0); button < sdl.CONTROLLER_BUTTON_MAX; button++ {
+			down := controller.Button(button) == 1
+			if down && !ui.prevButtons[button] && !found {
+				pressed = button
+				found = true
+			}
+		}
+	}
+	for button := sdl.GameControllerButton(0); button < sdl.CONTROLLER_BUTTON_MAX; button++ {
+		ui.prevButtons[button] = false
+		for _, controller := range ui.controllers {
+			if controller.Button(button) == 1 {
+				ui.prevButtons[button] = true
+			}
+		}
+	}
+	return pressed, found
+}
@@ -0,0 +1,97 @@
+package ui2d
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/LucasK1/gameswithgo/rpg/game"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+func writeBindings(t *testing.T, b bindings) string {
+	t.Helper()
+	var sb strings.Builder
+	err := b.write(&sb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sb.String()
+}
+
+func TestReadBindings(t *testing.T) {
+	b, err := readBindings(strings.NewReader(`
+# wasd as well as the arrows
+up key W
+up key Up
+up pad dpup
+search key Space
+search pad a
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	up := b.get(game.Up)
+	if !reflect.DeepEqual(up.keys, []sdl.Scancode{sdl.SCANCODE_W, sdl.SCANCODE_UP}) || !reflect.DeepEqual(up.buttons, []sdl.GameControllerButton{sdl.CONTROLLER_BUTTON_DPAD_UP}) {
+		t.Errorf("up bound to %+v, want W, Up and dpup", up)
+	}
+	search := b.get(game.Search)
+	if !reflect.DeepEqual(search.keys, []sdl.Scancode{sdl.SCANCODE_SPACE}) || !reflect.DeepEqual(search.buttons, []sdl.GameControllerButton{sdl.CONTROLLER_BUTTON_A}) {
+		t.Errorf("search bound to %+v, want Space and a", search)
+	}
+	if down := b.get(game.Down); len(down.keys) != 0 || len(down.buttons) != 0 {
+		t.Errorf("down bound to %+v, want nothing", down)
+	}
+}
+
+func TestWriteBindings(t *testing.T) {
+	want := `up key Up
+up pad dpup
+down key Down
+down pad dpdown
+left key Left
+left pad dpleft
+right key Right
+right pad dpright
+`
+	if got := writeBindings(t, defaultBindings()); got != want {
+		t.Errorf("wrote\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestBindingsRoundTrip(t *testing.T) {
+	b := defaultBindings()
+	b.bindKey(game.Search, sdl.SCANCODE_UP)
+	b.bindButton(game.Search, sdl.CONTROLLER_BUTTON_A)
+	if len(b.get(game.Up).keys) != 0 {
+		t.Errorf("Up still moves up after binding it to search")
+	}
+
+	written := writeBindings(t, b)
+	again, err := readBindings(strings.NewReader(written))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := writeBindings(t, again); got != written {
+		t.Errorf("read back as\n%s\nwant\n%s", got, written)
+	}
+	search := again.get(game.Search)
+	if !reflect.DeepEqual(search.keys, []sdl.Scancode{sdl.SCANCODE_UP}) || !reflect.DeepEqual(search.buttons, []sdl.GameControllerButton{sdl.CONTROLLER_BUTTON_A}) {
+		t.Errorf("search read back bound to %+v, want Up and a", search)
+	}
+}
+
+func TestReadBindingsRejects(t *testing.T) {
+	for _, line := range []string{
+		"up key",
+		"jump key Space",
+		"up key NoSuchKey",
+		"up pad nosuchbutton",
+		"up mouse left",
+	} {
+		_, err := readBindings(strings.NewReader(line + "\n"))
+		if err == nil {
+			t.Errorf("%q accepted", line)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package ui2d
+
+import (
+	"math"
+
+	"github.com/LucasK1/gameswithgo/rpg/game"
+)
+
+// zoomLevels are the tile sizes in pixels the camera can zoom between.
+var zoomLevels = []int32{16, 24, 32, 48, 64}
+
+const (
+	defaultZoom = 2
+	// deadZone is how many tiles the followed position may stray from the
+	// centre of the screen, on either axis, before the camera moves.
+	deadZone = 4
+	// scrollSpeed is how quickly the camera closes the gap to its target;
+	// higher is snappier.
+	scrollSpeed = 10.0
+)
+
+// camera tracks which map tile is in the middle of the window. It eases
+// towards its target over real time rather than per turn, so it has to be
+// updated every frame.
+type camera struct {
+	x, y             float64
+	targetX, targetY float64
+	zoom             int
+	placed           bool
+	lastTick         uint32
+}
+
+func newCamera() camera {
+	return camera{zoom: defaultZoom}
+}
+
+func (c *camera) tileSize() int32 {
+	return zoomLevels[c.zoom]
+}
+
+func (c *camera) zoomIn() bool {
+	if c.zoom < len(zoomLevels)-1 {
+		c.zoom++
+		return true
+	}
+	return false
+}
+
+func (c *camera) zoomOut() bool {
+	if c.zoom > 0 {
+		c.zoom--
+		return true
+	}
+	return false
+}
+
+// follow moves the target so that pos is inside the dead zone. The first
+// position followed is jumped to straight away.
+func (c *camera) follow(pos game.Pos) {
+	x := float64(pos.X)
+	y := float64(pos.Y)
+	if !c.placed {
+		c.x, c.y = x, y
+		c.targetX, c.targetY = x, y
+		c.placed = true
+		return
+	}
+
+	if x > c.targetX+deadZone {
+		c.targetX = x - deadZone
+	} else if x < c.targetX-deadZone {
+		c.targetX = x + deadZone
+	}
+	if y > c.targetY+deadZone {
+		c.targetY = y - deadZone
+	} else if y < c.targetY-deadZone {
+		c.targetY = y + deadZone
+	}
+}
+
+// jump centres the camera on pos without scrolling.
+func (c *camera) jump(pos game.Pos) {
+	c.placed = false
+	c.follow(pos)
+}
+
+// update moves the camera towards its target for the time passed since the
+// last update and reports whether it moved.
+func (c *camera) update(now uint32) bool {
+	dt := float64(now-c.lastTick) / 1000
+	c.lastTick = now
+	if c.x == c.targetX && c.y == c.targetY {
+		return false
+	}
+
+	k := 1 - math.Exp(-dt*scrollSpeed)
+	c.x += (c.targetX - c.x) * k
+	c.y += (c.targetY - c.y) * k
+	if math.Abs(c.targetX-c.x) < 0.01 && math.Abs(c.targetY-c.y) < 0.01 {
+		c.x = c.targetX
+		c.y = c.targetY
+	}
+	return true
+}
+
+// offset is where the top left corner of tile 0,0 goes in a window of the
+// given size.
+func (c *camera) offset(winWidth, winHeight int) (int32, int32) {
+	ts := float64(c.tileSize())
+	offsetX := int32(float64(winWidth/2) - c.x*ts)
+	offsetY := int32(float64(winHeight/2) - c.y*ts)
+	return offsetX, offsetY
+}
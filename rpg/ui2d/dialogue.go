@@ -0,0 +1,116 @@
+package ui2d
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/LucasK1/gameswithgo/rpg/game"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// dialogueInput turns the keys pressed during a conversation into the answer
+// to send: 1 to 9 pick a choice, Return the first and Escape leaves.
+func (ui *ui) dialogueInput(talk *game.DialogueView) *game.Input {
+	key, pressed := ui.pressedKey()
+	if !pressed {
+		return nil
+	}
+	switch {
+	case key >= sdl.SCANCODE_1 && key <= sdl.SCANCODE_9:
+		choice := int(key - sdl.SCANCODE_1)
+		if choice >= len(talk.Choices) {
+			return nil
+		}
+		return &game.Input{Type: game.Choose, Choice: choice}
+	case key == sdl.SCANCODE_RETURN:
+		return &game.Input{Type: game.Choose, Choice: 0}
+	case key == sdl.SCANCODE_ESCAPE:
+		return &game.Input{Type: game.Choose, Choice: -1}
+	}
+	return nil
+}
+
+// wrapText splits s into lines no wider than width in the small font.
+func (ui *ui) wrapText(s string, width int) []string {
+	lines := make([]string, 0)
+	for _, paragraph := range strings.Split(s, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			next := word
+			if line != "" {
+				next = line + " " + word
+			}
+			w, _, err := ui.fontSmall.SizeUTF8(next)
+			if err != nil {
+				panic(err)
+			}
+			if w > width && line != "" {
+				lines = append(lines, line)
+				next = word
+			}
+			line = next
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// drawDialogue draws the conversation in a panel along the bottom of the
+// window.
+func (ui *ui) drawDialogue(talk *game.DialogueView) {
+	white := sdl.Color{R: 255, G: 255, B: 255, A: 0}
+	yellow := sdl.Color{R: 255, G: 255, B: 0, A: 0}
+	grey := sdl.Color{R: 160, G: 160, B: 160, A: 0}
+
+	margin := int32(ui.winWidth / 20)
+	width := int32(ui.winWidth) - 2*margin
+	lines := ui.wrapText(talk.Text, int(width)-20)
+	_, lineHeight, _ := ui.fontSmall.SizeUTF8("A")
+	_, speakerHeight, _ := ui.fontMedium.SizeUTF8("A")
+	rows := len(lines) + len(talk.Choices) + 2
+	height := int32(speakerHeight) + int32(rows*lineHeight) + 20
+	top := int32(ui.winHeight) - height - margin
+
+	ui.renderer.Copy(ui.eventBackground, nil, &sdl.Rect{X: margin, Y: top, W: width, H: height})
+
+	x := margin + 10
+	y := top + 10
+	y += ui.drawText(talk.Speaker, yellow, FontMedium, x, y)
+	for _, line := range lines {
+		if line != "" {
+			ui.drawText(line, white, FontSmall, x, y)
+		}
+		y += int32(lineHeight)
+	}
+	y += int32(lineHeight)
+	for i, choice := range talk.Choices {
+		y += ui.drawText(strconv.Itoa(i+1)+". "+choice, yellow, FontSmall, x, y)
+	}
+	if len(talk.Choices) == 0 {
+		ui.drawText("Return: leave", grey, FontSmall, x, y)
+	} else {
+		ui.drawText("Esc: leave", grey, FontSmall, x, y)
+	}
+}
+
+// drawQuests lists the quests the player has started, and how far they have
+// got, down the left of the window from y.
+func (ui *ui) drawQuests(quests []game.QuestView, y int32) {
+	white := sdl.Color{R: 255, G: 255, B: 255, A: 0}
+	yellow := sdl.Color{R: 255, G: 255, B: 0, A: 0}
+	grey := sdl.Color{R: 160, G: 160, B: 160, A: 0}
+
+	for _, q := range quests {
+		switch q.Status {
+		case game.QuestDone:
+			continue
+		case game.QuestComplete:
+			y += ui.drawText(q.Title+" (complete)", yellow, FontSmall, 10, y)
+		default:
+			y += ui.drawText(q.Title, white, FontSmall, 10, y)
+		}
+		for _, objective := range q.Objectives {
+			y += ui.drawText("  "+objective, grey, FontSmall, 10, y)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package ui2d
+
+import (
+	"testing"
+
+	"github.com/LucasK1/gameswithgo/rpg/game"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// The benchmarks draw with SDL's software renderer into a surface, so they
+// run without a display when started with
+//
+//	SDL_VIDEODRIVER=dummy SDL_AUDIODRIVER=dummy go test -run NONE -bench Draw ./rpg/ui2d
+
+func newBenchUI(b *testing.B) *ui {
+	surface, err := sdl.CreateRGBSurface(0, 1280, 720, 32, 0, 0, 0, 0)
+	if err != nil {
+		b.Fatal(err)
+	}
+	renderer, err := sdl.CreateSoftwareRenderer(surface)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return newUI(renderer, 1280, 720)
+}
+
+// benchMap is a few rooms with doors, stairs and monsters, about as busy as
+// the game's own levels.
+const benchMap = `
+	########################################
+	#@.....#..........#.......R...........u#
+	#......|..........|....................#
+	#......#....S.....#######/##############
+	#......#..........#......#.............#
+	##/#####..........#..R...#.............#
+	#......#####|######......|......S......#
+	#......#..........#......#.............#
+	#..R...#..........#......#.............#
+	#......|....S.....########.............#
+	#......#..........#....................#
+	#......#..........#.........R..........#
+	#d.....#..........|....................#
+	########################################
+`
+
+// newBenchGame builds a game on benchMap, so the benchmarks don't depend on
+// the maps in mapDir.
+func newBenchGame(b *testing.B) *game.Game {
+	g, err := game.NewGameFromMaps(b.TempDir(), 0, 1, "bench", map[string]string{"bench": benchMap})
+	if err != nil {
+		b.Fatal(err)
+	}
+	return g
+}
+
+// BenchmarkDraw draws the same snapshot over and over, as happens while the
+// camera scrolls or sprites animate.
+func BenchmarkDraw(b *testing.B) {
+	g := newBenchGame(b)
+	level := g.CurrentLevel.Snapshot()
+	ui := newBenchUI(b)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ui.Draw(level)
+	}
+}
+
+// BenchmarkDrawFOVChange alternates between two snapshots that differ in
+// which tiles are visible, so the terrain has to be partly redrawn each time.
+func BenchmarkDrawFOVChange(b *testing.B) {
+	g := newBenchGame(b)
+	lit := g.CurrentLevel.Snapshot()
+	dark := g.CurrentLevel.Snapshot()
+	for y, row := range dark.Map {
+		for x := range row {
+			dark.Map[y][x].Visible = false
+		}
+	}
+	ui := newBenchUI(b)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if i%2 == 0 {
+			ui.Draw(lit)
+		} else {
+			ui.Draw(dark)
+		}
+	}
+}
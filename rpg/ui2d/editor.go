@@ -0,0 +1,195 @@
+package ui2d
+
+import (
+	"strconv"
+
+	"github.com/LucasK1/gameswithgo/rpg/game"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// brushes are the .map characters the editor can paint, selected with the
+// number keys in this order.
+var brushes = []rune{'.', '#', ' ', '|', '/', 'u', 'd', '@', 'R', 'S'}
+
+var brushKeys = []uint8{
+	sdl.SCANCODE_1, sdl.SCANCODE_2, sdl.SCANCODE_3, sdl.SCANCODE_4, sdl.SCANCODE_5,
+	sdl.SCANCODE_6, sdl.SCANCODE_7, sdl.SCANCODE_8, sdl.SCANCODE_9, sdl.SCANCODE_0,
+}
+
+type portalEnd struct {
+	level string
+	pos   game.Pos
+}
+
+type editor struct {
+	active     bool
+	cursor     game.Pos
+	brush      int
+	portalFrom *portalEnd
+	lastPaint  game.Pos
+	painting   bool
+}
+
+// toggleEditor opens or closes the editor. Closing it returns the input
+// telling the game to show the player's level again.
+func (ui *ui) toggleEditor() *game.Input {
+	ui.editor.active = !ui.editor.active
+	ui.editor.portalFrom = nil
+	if !ui.editor.active {
+		return &game.Input{Type: game.CloseEditor}
+	}
+	if ui.level != nil {
+		ui.editor.cursor = ui.level.Player.Pos
+	}
+	return nil
+}
+
+// updateEditor reads the keyboard and mouse while editing. It returns the
+// edit to send to the game, if any, and whether the screen needs redrawing.
+// Only one input is sent per frame because the game blocks publishing the
+// result of the first until we read it.
+func (ui *ui) updateEditor() (*game.Input, bool) {
+	ed := &ui.editor
+	level := ui.level
+	if level == nil {
+		return nil, false
+	}
+	redraw := false
+
+	move := func(dx, dy int) {
+		next := game.Pos{X: ed.cursor.X + dx, Y: ed.cursor.Y + dy}
+		if next.Y >= 0 && next.Y < len(level.Map) && next.X >= 0 && next.X < len(level.Map[0]) {
+			ed.cursor = next
+			redraw = true
+		}
+	}
+	if ui.keyDownOnce(sdl.SCANCODE_UP) {
+		move(0, -1)
+	}
+	if ui.keyDownOnce(sdl.SCANCODE_DOWN) {
+		move(0, 1)
+	}
+	if ui.keyDownOnce(sdl.SCANCODE_LEFT) {
+		move(-1, 0)
+	}
+	if ui.keyDownOnce(sdl.SCANCODE_RIGHT) {
+		move(1, 0)
+	}
+
+	for i, key := range brushKeys {
+		if ui.keyDownOnce(key) {
+			ed.brush = i
+			redraw = true
+		}
+	}
+
+	if ui.keyDownOnce(sdl.SCANCODE_ESCAPE) && ed.portalFrom != nil {
+		ed.portalFrom = nil
+		redraw = true
+	}
+
+	mouseX, mouseY, buttons := sdl.GetMouseState()
+	if buttons&sdl.ButtonLMask() != 0 {
+		pos, ok := ui.tileAt(level, int(mouseX), int(mouseY))
+		if ok && (!ed.painting || pos != ed.lastPaint) {
+			ed.painting = true
+			ed.lastPaint = pos
+			ed.cursor = pos
+			return ui.paint(pos), true
+		}
+	} else {
+		ed.painting = false
+	}
+
+	switch {
+	case ui.keyDownOnce(sdl.SCANCODE_SPACE):
+		return ui.paint(ed.cursor), true
+	case ui.keyDownOnce(sdl.SCANCODE_P):
+		if ed.portalFrom == nil {
+			ed.portalFrom = &portalEnd{level: level.Name, pos: ed.cursor}
+			return nil, true
+		}
+		edit := &game.Edit{Level: ed.portalFrom.level, Pos: ed.portalFrom.pos, Target: ed.cursor}
+		ed.portalFrom = nil
+		return &game.Input{Type: game.EditPortal, Edit: edit}, true
+	case ui.keyDownOnce(sdl.SCANCODE_X):
+		return &game.Input{Type: game.EditRemovePortal, Edit: &game.Edit{Pos: ed.cursor}}, true
+	case ui.keyDownOnce(sdl.SCANCODE_PAGEUP):
+		return &game.Input{Type: game.EditPrevLevel}, true
+	case ui.keyDownOnce(sdl.SCANCODE_PAGEDOWN):
+		return &game.Input{Type: game.EditNextLevel}, true
+	case ui.keyDownOnce(sdl.SCANCODE_F5):
+		return &game.Input{Type: game.SaveWorld}, true
+	}
+
+	return nil, redraw
+}
+
+func (ui *ui) paint(pos game.Pos) *game.Input {
+	return &game.Input{Type: game.EditTile, Edit: &game.Edit{Pos: pos, Rune: brushes[ui.editor.brush]}}
+}
+
+// tileAt converts window coordinates to the map tile drawn there.
+func (ui *ui) tileAt(level *game.Snapshot, x, y int) (game.Pos, bool) {
+	ts := int(ui.camera.tileSize())
+	offsetX, offsetY := ui.camera.offset(ui.winWidth, ui.winHeight)
+	if x < int(offsetX) || y < int(offsetY) {
+		return game.Pos{}, false
+	}
+	pos := game.Pos{X: (x - int(offsetX)) / ts, Y: (y - int(offsetY)) / ts}
+	if pos.Y >= len(level.Map) || pos.X >= len(level.Map[0]) {
+		return game.Pos{}, false
+	}
+	return pos, true
+}
+
+func (ui *ui) drawEditor(level *game.Snapshot, offsetX, offsetY int32) {
+	ed := &ui.editor
+	ts := ui.camera.tileSize()
+
+	ui.renderer.SetDrawColor(0, 255, 255, 255)
+	for pos := range level.Portals {
+		ui.renderer.DrawRect(&sdl.Rect{X: int32(pos.X)*ts + offsetX, Y: int32(pos.Y)*ts + offsetY, W: ts, H: ts})
+	}
+
+	if ed.portalFrom != nil && ed.portalFrom.level == level.Name {
+		pos := ed.portalFrom.pos
+		ui.renderer.SetDrawColor(255, 0, 255, 255)
+		ui.renderer.DrawRect(&sdl.Rect{X: int32(pos.X)*ts + offsetX, Y: int32(pos.Y)*ts + offsetY, W: ts, H: ts})
+	}
+
+	ui.renderer.SetDrawColor(255, 255, 0, 255)
+	ui.renderer.DrawRect(&sdl.Rect{X: int32(ed.cursor.X)*ts + offsetX, Y: int32(ed.cursor.Y)*ts + offsetY, W: ts, H: ts})
+
+	paletteY := int32(ui.winHeight - 40)
+	paletteX := int32(ui.winWidth) - int32(len(brushes))*36 - 4
+	ui.renderer.Copy(ui.eventBackground, nil, &sdl.Rect{X: paletteX - 4, Y: paletteY - 4, W: int32(len(brushes))*36 + 4, H: 40})
+	for i, brush := range brushes {
+		dstRect := sdl.Rect{X: paletteX + int32(i)*36, Y: paletteY, W: 32, H: 32}
+		if brush != ' ' {
+			ui.drawSprite(game.DirtFloor, 0, &dstRect)
+			ui.drawSprite(brush, 0, &dstRect)
+		}
+		if i == ed.brush {
+			ui.renderer.SetDrawColor(255, 255, 0, 255)
+			ui.renderer.DrawRect(&sdl.Rect{X: dstRect.X - 2, Y: dstRect.Y - 2, W: 36, H: 36})
+		}
+	}
+	ui.renderer.SetDrawColor(0, 0, 0, 255)
+
+	status := "EDIT " + level.Name + "  " + strconv.Itoa(ed.cursor.X) + "," + strconv.Itoa(ed.cursor.Y)
+	dest, exists := level.Portals[ed.cursor]
+	if exists {
+		status += "  portal to " + dest.Level + " " + strconv.Itoa(dest.Pos.X) + "," + strconv.Itoa(dest.Pos.Y)
+	}
+	if ed.portalFrom != nil {
+		status += "  linking from " + ed.portalFrom.level + " " + strconv.Itoa(ed.portalFrom.pos.X) + "," + strconv.Itoa(ed.portalFrom.pos.Y)
+	}
+	tex := ui.stringToTexture(status, sdl.Color{R: 255, G: 255, B: 0, A: 0}, FontSmall)
+	_, _, w, h, err := tex.Query()
+	if err != nil {
+		panic(err)
+	}
+	ui.renderer.Copy(ui.eventBackground, nil, &sdl.Rect{X: 0, Y: 0, W: w + 10, H: h + 4})
+	ui.renderer.Copy(tex, nil, &sdl.Rect{X: 5, Y: 2, W: w, H: h})
+}
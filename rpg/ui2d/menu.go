@@ -0,0 +1,276 @@
+package ui2d
+
+import (
+	"strconv"
+
+	"github.com/LucasK1/gameswithgo/rpg/game"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+type menuScreen int
+
+const (
+	noMenu menuScreen = iota
+	mainMenu
+	pauseMenu
+	loadMenu
+	saveMenu
+	optionsMenu
+)
+
+// menu is the screens around the game: the main menu, the pause menu Escape
+// opens during play, the save slots and the options. Like the rebinding
+// screen they are driven by the arrow keys, Return and Escape, whatever is
+// bound. The game is paused while any of them is open.
+type menu struct {
+	screen   menuScreen
+	selected int
+	// parent is the menu Escape goes back to from the save, load and options
+	// screens.
+	parent menuScreen
+	// local is whether the game is played on this computer, so that it can be
+	// started, saved and loaded from the menus. canContinue is whether there
+	// is a game to go back to from the main menu.
+	local       bool
+	canContinue bool
+	slots       []game.SaveInfo
+}
+
+// menuItem is one line of a menu. Items without do are greyed out, and items
+// with a value change it with Left and Right through adjust. do returns the
+// input to send to the game, if any.
+type menuItem struct {
+	label  string
+	value  string
+	do     func() *game.Input
+	adjust func(delta int)
+}
+
+// ShowMenu opens the main menu, for a UI playing a game on this computer.
+func (ui *ui) ShowMenu() {
+	ui.menu.local = true
+	ui.menu.canContinue = game.SavedGames()[0].Level != ""
+	ui.openMenu(mainMenu)
+}
+
+func (ui *ui) openMenu(screen menuScreen) {
+	m := &ui.menu
+	switch screen {
+	case loadMenu, saveMenu, optionsMenu:
+		if m.screen != screen {
+			m.parent = m.screen
+		}
+	}
+	if screen == loadMenu || screen == saveMenu {
+		m.slots = game.SavedGames()
+	}
+	m.screen = screen
+	m.selected = 0
+}
+
+// closeMenu goes back to the game, or back up from the save, load and
+// options screens.
+func (ui *ui) closeMenu() *game.Input {
+	m := &ui.menu
+	switch m.screen {
+	case optionsMenu:
+		err := ui.audio.save()
+		if err != nil {
+			panic(err)
+		}
+		fallthrough
+	case loadMenu, saveMenu:
+		ui.openMenu(m.parent)
+		return nil
+	}
+	m.screen = noMenu
+	return &game.Input{Type: game.Resume}
+}
+
+// play leaves the menus to play the game as it is after input.
+func (ui *ui) play(input *game.Input) *game.Input {
+	ui.menu.screen = noMenu
+	ui.menu.canContinue = true
+	return input
+}
+
+func (ui *ui) menuItems() []menuItem {
+	m := &ui.menu
+	open := func(screen menuScreen) func() *game.Input {
+		return func() *game.Input {
+			ui.openMenu(screen)
+			return nil
+		}
+	}
+	quit := func() *game.Input {
+		return &game.Input{Type: game.QuitGame}
+	}
+
+	switch m.screen {
+	case mainMenu:
+		items := []menuItem{
+			{label: "New game", do: func() *game.Input { return ui.play(&game.Input{Type: game.NewRun}) }},
+			{label: "Continue"},
+			{label: "Load game", do: open(loadMenu)},
+			{label: "Options", do: open(optionsMenu)},
+			{label: "Quit", do: quit},
+		}
+		if m.canContinue {
+			items[1].do = func() *game.Input { return ui.play(&game.Input{Type: game.Resume}) }
+		}
+		return items
+
+	case pauseMenu:
+		if !m.local {
+			return []menuItem{
+				{label: "Resume", do: ui.closeMenu},
+				{label: "Options", do: open(optionsMenu)},
+				{label: "Quit", do: quit},
+			}
+		}
+		return []menuItem{
+			{label: "Resume", do: ui.closeMenu},
+			{label: "Save game", do: open(saveMenu)},
+			{label: "Load game", do: open(loadMenu)},
+			{label: "Options", do: open(optionsMenu)},
+			{label: "Main menu", do: open(mainMenu)},
+			{label: "Quit", do: quit},
+		}
+
+	case loadMenu, saveMenu:
+		items := make([]menuItem, 0, len(m.slots)+1)
+		for _, info := range m.slots {
+			slot := info.Slot
+			if m.screen == saveMenu && slot == 0 {
+				continue
+			}
+			item := menuItem{label: slotName(slot), value: "empty"}
+			if info.Level != "" {
+				item.value = info.Level + ", turn " + strconv.Itoa(info.Turn) + ", " + info.Saved.Format("2 Jan 15:04")
+			}
+			switch {
+			case m.screen == saveMenu:
+				item.do = func() *game.Input {
+					ui.closeMenu()
+					return &game.Input{Type: game.SaveGame, Slot: slot}
+				}
+			case info.Level != "":
+				item.do = func() *game.Input { return ui.play(&game.Input{Type: game.LoadGame, Slot: slot}) }
+			}
+			items = append(items, item)
+		}
+		return append(items, menuItem{label: "Back", do: ui.closeMenu})
+
+	case optionsMenu:
+		a := ui.audio
+		sound := "on"
+		if a.mute {
+			sound = "off"
+		}
+		window := "windowed"
+		if ui.window != nil && ui.window.GetFlags()&sdl.WINDOW_FULLSCREEN_DESKTOP != 0 {
+			window = "fullscreen"
+		}
+		return []menuItem{
+			{label: "Master volume", value: strconv.Itoa(a.master) + "%", adjust: func(delta int) { a.changeMaster(10 * delta) }},
+			{label: "Music volume", value: strconv.Itoa(a.music) + "%", adjust: func(delta int) { a.changeMusic(10 * delta) }},
+			{label: "Effects volume", value: strconv.Itoa(a.effects) + "%", adjust: func(delta int) { a.changeEffects(10 * delta) }},
+			{label: "Sound", value: sound, do: func() *game.Input { a.toggleMute(); return nil }},
+			{label: "Window", value: window, do: func() *game.Input { ui.toggleFullscreen(); return nil }},
+			{label: "Key bindings", do: func() *game.Input { ui.toggleRebind(); return nil }},
+			{label: "Back", do: ui.closeMenu},
+		}
+	}
+	return nil
+}
+
+func slotName(slot int) string {
+	if slot == 0 {
+		return "Autosave"
+	}
+	return "Slot " + strconv.Itoa(slot)
+}
+
+// updateMenu handles one frame of keys in the open menu, returning the input
+// to send, if any, and whether the menu needs redrawing.
+func (ui *ui) updateMenu() (*game.Input, bool) {
+	m := &ui.menu
+	items := ui.menuItems()
+	m.selected = clamp(m.selected, 0, len(items)-1)
+	item := items[m.selected]
+
+	switch {
+	case ui.keyDownOnce(sdl.SCANCODE_UP):
+		m.selected = (m.selected + len(items) - 1) % len(items)
+	case ui.keyDownOnce(sdl.SCANCODE_DOWN):
+		m.selected = (m.selected + 1) % len(items)
+	case ui.keyDownOnce(sdl.SCANCODE_LEFT) && item.adjust != nil:
+		item.adjust(-1)
+	case ui.keyDownOnce(sdl.SCANCODE_RIGHT) && item.adjust != nil:
+		item.adjust(1)
+	case ui.keyDownOnce(sdl.SCANCODE_RETURN) && item.do != nil:
+		return item.do(), true
+	case ui.keyDownOnce(sdl.SCANCODE_ESCAPE):
+		if m.screen == mainMenu {
+			return nil, false
+		}
+		return ui.closeMenu(), true
+	default:
+		return nil, false
+	}
+	return nil, true
+}
+
+var menuTitles = map[menuScreen]string{
+	mainMenu:    "RPG",
+	pauseMenu:   "Paused",
+	loadMenu:    "Load game",
+	saveMenu:    "Save game",
+	optionsMenu: "Options",
+}
+
+// drawMenu draws the open menu, over a black screen for the main menu and
+// over the darkened game for the others.
+func (ui *ui) drawMenu() {
+	white := sdl.Color{R: 255, G: 255, B: 255, A: 0}
+	yellow := sdl.Color{R: 255, G: 255, B: 0, A: 0}
+	grey := sdl.Color{R: 160, G: 160, B: 160, A: 0}
+
+	if ui.menu.screen == mainMenu {
+		ui.renderer.Clear()
+	}
+	ui.renderer.Copy(ui.eventBackground, nil, nil)
+
+	x := int32(ui.winWidth / 4)
+	y := int32(ui.winHeight / 6)
+	size := FontMedium
+	if ui.menu.screen == mainMenu {
+		size = FontLarge
+	}
+	y += ui.drawText(menuTitles[ui.menu.screen], white, size, x, y)
+	y += 20
+
+	for i, item := range ui.menuItems() {
+		color := white
+		prefix := "  "
+		if item.do == nil && item.adjust == nil {
+			color = grey
+		}
+		if i == ui.menu.selected {
+			color = yellow
+			prefix = "> "
+		}
+		h := ui.drawText(prefix+item.label, color, FontSmall, x, y)
+		if item.value != "" {
+			value := item.value
+			if item.adjust != nil {
+				value = "< " + value + " >"
+			}
+			ui.drawText(value, color, FontSmall, x+int32(ui.winWidth/4), y)
+		}
+		y += h + 4
+	}
+
+	y += 20
+	ui.drawText("Up/Down: choose   Left/Right: change   Return: select   Esc: back", grey, FontSmall, x, y)
+}
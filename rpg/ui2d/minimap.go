@@ -0,0 +1,147 @@
+package ui2d
+
+import (
+	"bytes"
+
+	"github.com/LucasK1/gameswithgo/rpg/game"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// minimap keeps a texture of the explored map with one pixel per tile. The
+// pixels are worked out for every snapshot, but only uploaded when they
+// differ from the last ones, which is whenever the field of view changed.
+type minimap struct {
+	tex        *sdl.Texture
+	level      string
+	width      int
+	height     int
+	pixels     []byte
+	prevPixels []byte
+	fullscreen bool
+}
+
+const (
+	minimapScale = 3
+	minimapMax   = 240
+)
+
+// tileColor is the colour of tile on the map, transparent if it hasn't been
+// seen yet.
+func tileColor(tile game.Tile) sdl.Color {
+	if !tile.Seen {
+		return sdl.Color{}
+	}
+	var c sdl.Color
+	switch tile.OverlayRune {
+	case game.ClosedDoor, game.OpenDoor:
+		c = sdl.Color{R: 200, G: 120, B: 40, A: 255}
+	case game.UpStair, game.DownStair:
+		c = sdl.Color{R: 60, G: 220, B: 220, A: 255}
+	default:
+		switch tile.Rune {
+		case game.StoneWall:
+			c = sdl.Color{R: 150, G: 150, B: 150, A: 255}
+		case game.Blank:
+			return sdl.Color{}
+		default:
+			c = sdl.Color{R: 90, G: 70, B: 50, A: 255}
+		}
+	}
+	if !tile.Visible {
+		c.R /= 2
+		c.G /= 2
+		c.B /= 2
+	}
+	return c
+}
+
+// updateMinimap brings the minimap texture up to date with level.
+func (ui *ui) updateMinimap(level *game.Snapshot) {
+	mm := &ui.minimap
+	height := len(level.Map)
+	width := 0
+	if height > 0 {
+		width = len(level.Map[0])
+	}
+
+	if mm.tex == nil || mm.level != level.Name || mm.width != width || mm.height != height {
+		if mm.tex != nil {
+			mm.tex.Destroy()
+		}
+		tex, err := ui.renderer.CreateTexture(sdl.PIXELFORMAT_ABGR8888, sdl.TEXTUREACCESS_STATIC, int32(width), int32(height))
+		if err != nil {
+			panic(err)
+		}
+		err = tex.SetBlendMode(sdl.BLENDMODE_BLEND)
+		if err != nil {
+			panic(err)
+		}
+		mm.tex = tex
+		mm.level = level.Name
+		mm.width = width
+		mm.height = height
+		mm.pixels = make([]byte, width*height*4)
+		mm.prevPixels = nil
+	}
+
+	i := 0
+	for _, row := range level.Map {
+		for _, tile := range row {
+			c := tileColor(tile)
+			mm.pixels[i] = c.R
+			mm.pixels[i+1] = c.G
+			mm.pixels[i+2] = c.B
+			mm.pixels[i+3] = c.A
+			i += 4
+		}
+	}
+
+	if bytes.Equal(mm.pixels, mm.prevPixels) {
+		return
+	}
+	mm.tex.Update(nil, mm.pixels, width*4)
+	mm.pixels, mm.prevPixels = mm.prevPixels, mm.pixels
+	if mm.pixels == nil {
+		mm.pixels = make([]byte, width*height*4)
+	}
+}
+
+// drawMinimap draws the explored map in the top right corner, or scaled to
+// fit the window when the full map view is on.
+func (ui *ui) drawMinimap(level *game.Snapshot) {
+	mm := &ui.minimap
+	if mm.tex == nil || mm.width == 0 || mm.height == 0 {
+		return
+	}
+
+	var scale, x, y int32
+	if mm.fullscreen {
+		margin := int32(40)
+		scaleX := (int32(ui.winWidth) - 2*margin) / int32(mm.width)
+		scaleY := (int32(ui.winHeight) - 2*margin) / int32(mm.height)
+		scale = scaleX
+		if scaleY < scale {
+			scale = scaleY
+		}
+		if scale < 1 {
+			scale = 1
+		}
+		x = (int32(ui.winWidth) - scale*int32(mm.width)) / 2
+		y = (int32(ui.winHeight) - scale*int32(mm.height)) / 2
+	} else {
+		scale = minimapScale
+		for scale > 1 && (int32(mm.width)*scale > minimapMax || int32(mm.height)*scale > minimapMax) {
+			scale--
+		}
+		x = int32(ui.winWidth) - scale*int32(mm.width) - 10
+		y = 10
+		ui.renderer.Copy(ui.eventBackground, nil, &sdl.Rect{X: x - 4, Y: y - 4, W: scale*int32(mm.width) + 8, H: scale*int32(mm.height) + 8})
+	}
+
+	ui.renderer.Copy(mm.tex, nil, &sdl.Rect{X: x, Y: y, W: scale * int32(mm.width), H: scale * int32(mm.height)})
+
+	p := level.Player.Pos
+	ui.renderer.SetDrawColor(255, 255, 0, 255)
+	ui.renderer.FillRect(&sdl.Rect{X: x + int32(p.X)*scale, Y: y + int32(p.Y)*scale, W: scale, H: scale})
+	ui.renderer.SetDrawColor(0, 0, 0, 255)
+}
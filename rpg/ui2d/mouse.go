@@ -0,0 +1,138 @@
+package ui2d
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/LucasK1/gameswithgo/rpg/game"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// travelDelay is how long, in milliseconds, each step of a walk started with
+// the mouse is on screen before the next is taken.
+const travelDelay = 120
+
+// mouse is what the mouse is doing over the map. Clicking a tile in sight
+// walks there and clicking a monster goes after it, one step a turn, until
+// the game says the walk is over. Hovering over a tile names what is on it.
+type mouse struct {
+	hover    game.Pos
+	hovering bool
+	down     bool
+	// stepped is the snapshot the last Travel was sent from, so the next is
+	// only sent once the game has answered it.
+	stepped  *game.Snapshot
+	lastStep uint32
+}
+
+// updateMouse handles one frame of the mouse over the map, returning the input
+// to send, if any, and whether the tooltip needs redrawing.
+func (ui *ui) updateMouse(now uint32) (*game.Input, bool) {
+	m := &ui.mouse
+	level := ui.level
+	x, y, buttons := sdl.GetMouseState()
+	pos, ok := ui.tileAt(level, int(x), int(y))
+	ok = ok && level.Map[pos.Y][pos.X].Seen
+
+	redraw := ok != m.hovering || ok && pos != m.hover
+	m.hover, m.hovering = pos, ok
+
+	clicked := buttons&sdl.ButtonLMask() != 0 && !m.down
+	m.down = buttons&sdl.ButtonLMask() != 0
+	switch {
+	case clicked && ok && level.Map[pos.Y][pos.X].Visible && pos != level.Player.Pos:
+	case level.Travel != nil && m.stepped != level && now-m.lastStep >= travelDelay:
+		pos = *level.Travel
+	default:
+		return nil, redraw
+	}
+	m.stepped = level
+	m.lastStep = now
+	return &game.Input{Type: game.Travel, Pos: pos}, redraw
+}
+
+var tileNames = map[rune]string{
+	game.StoneWall:  "Stone wall",
+	game.DirtFloor:  "Dirt floor",
+	game.ClosedDoor: "Closed door",
+	game.OpenDoor:   "Open door",
+	game.UpStair:    "Stairs up",
+	game.DownStair:  "Stairs down",
+}
+
+// tooltip names what is at pos: whoever stands there and what lies there if
+// the player can see it, and the tile itself.
+func tooltip(level *game.Snapshot, pos game.Pos) []string {
+	var lines []string
+	tile := level.Map[pos.Y][pos.X]
+	if tile.Visible {
+		health := func(c game.Character) string {
+			return c.Name + " (HP " + strconv.Itoa(c.HP) + ")"
+		}
+		if pos == level.Player.Pos {
+			lines = append(lines, health(level.Player.Character))
+		}
+		if other, exists := level.Others[pos]; exists {
+			lines = append(lines, health(other.Character))
+		}
+		if monster, exists := level.Monsters[pos]; exists {
+			lines = append(lines, health(monster.Character))
+		}
+		if npc, exists := level.NPCs[pos]; exists {
+			lines = append(lines, npc.Name)
+		}
+		if loot, exists := level.Loot[pos]; exists {
+			found := make([]string, 0, len(loot.Items)+1)
+			if loot.Gold > 0 {
+				found = append(found, strconv.Itoa(loot.Gold)+" gold")
+			}
+			lines = append(lines, strings.Join(append(found, loot.Items...), ", "))
+		}
+		if corpse, exists := level.Corpses[pos]; exists {
+			lines = append(lines, corpse.Monster+" corpse")
+		}
+	}
+	if name, exists := tileNames[tile.OverlayRune]; exists {
+		lines = append(lines, name)
+	} else if name, exists := tileNames[tile.Rune]; exists {
+		lines = append(lines, name)
+	}
+	return lines
+}
+
+// drawTooltip draws the tooltip for the tile under the mouse beside it.
+func (ui *ui) drawTooltip(level *game.Snapshot) {
+	if !ui.mouse.hovering {
+		return
+	}
+	lines := tooltip(level, ui.mouse.hover)
+	if len(lines) == 0 {
+		return
+	}
+	width, height := 0, 0
+	for _, line := range lines {
+		w, h, err := ui.fontSmall.SizeUTF8(line)
+		if err != nil {
+			panic(err)
+		}
+		if w > width {
+			width = w
+		}
+		height += h
+	}
+
+	x, y, _ := sdl.GetMouseState()
+	x += 16
+	y += 16
+	if int(x)+width+8 > ui.winWidth {
+		x -= int32(width) + 40
+	}
+	if int(y)+height+8 > ui.winHeight {
+		y -= int32(height) + 40
+	}
+	ui.renderer.Copy(ui.eventBackground, nil, &sdl.Rect{X: x, Y: y, W: int32(width) + 8, H: int32(height) + 8})
+	y += 4
+	for _, line := range lines {
+		y += ui.drawText(line, sdl.Color{R: 255, G: 255, B: 255, A: 0}, FontSmall, x+4, y)
+	}
+}
@@ -0,0 +1,112 @@
+package ui2d
+
+import (
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// rebindScreen lets the player change the bindings. The screen itself is
+// always driven by the arrow keys, Return, Backspace and Escape so that it
+// stays usable whatever gets bound.
+type rebindScreen struct {
+	active   bool
+	selected int
+	waiting  bool
+}
+
+func (ui *ui) toggleRebind() {
+	ui.rebind.active = !ui.rebind.active
+	ui.rebind.waiting = false
+	if !ui.rebind.active {
+		err := ui.bindings.save()
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+// updateRebind handles one frame of the rebinding screen and reports whether
+// it needs redrawing.
+func (ui *ui) updateRebind(button sdl.GameControllerButton, buttonPressed bool) bool {
+	rb := &ui.rebind
+	selected := actions[rb.selected].input
+
+	if rb.waiting {
+		key, keyPressed := ui.pressedKey()
+		switch {
+		case keyPressed && key == sdl.SCANCODE_ESCAPE:
+			rb.waiting = false
+		case keyPressed:
+			ui.bindings.bindKey(selected, key)
+			rb.waiting = false
+		case buttonPressed:
+			ui.bindings.bindButton(selected, button)
+			rb.waiting = false
+		default:
+			return false
+		}
+		return true
+	}
+
+	switch {
+	case ui.keyDownOnce(sdl.SCANCODE_UP):
+		rb.selected = (rb.selected + len(actions) - 1) % len(actions)
+	case ui.keyDownOnce(sdl.SCANCODE_DOWN):
+		rb.selected = (rb.selected + 1) % len(actions)
+	case ui.keyDownOnce(sdl.SCANCODE_RETURN):
+		rb.waiting = true
+	case ui.keyDownOnce(sdl.SCANCODE_BACKSPACE), ui.keyDownOnce(sdl.SCANCODE_DELETE):
+		bind := ui.bindings.get(selected)
+		bind.keys = nil
+		bind.buttons = nil
+	case ui.keyDownOnce(sdl.SCANCODE_ESCAPE):
+		ui.toggleRebind()
+	default:
+		return false
+	}
+	return true
+}
+
+func (ui *ui) drawRebind() {
+	ui.renderer.Clear()
+
+	white := sdl.Color{R: 255, G: 255, B: 255, A: 0}
+	yellow := sdl.Color{R: 255, G: 255, B: 0, A: 0}
+
+	y := int32(40)
+	y += ui.drawText("Controls", white, FontMedium, 40, y)
+	y += 20
+
+	for i, a := range actions {
+		color := white
+		prefix := "  "
+		if i == ui.rebind.selected {
+			color = yellow
+			prefix = "> "
+		}
+		ui.drawText(prefix+a.name, color, FontSmall, 40, y)
+		bound := ui.bindings.describe(a.input)
+		if i == ui.rebind.selected && ui.rebind.waiting {
+			bound = "press a key or button..."
+		}
+		y += ui.drawText(bound, color, FontSmall, 240, y)
+	}
+
+	y += 20
+	ui.drawText("Return: add binding   Backspace: clear   Esc: done", white, FontSmall, 40, y)
+
+	ui.renderer.Present()
+}
+
+// drawText draws s with its top left corner at x, y and returns its height.
+// Textures are cached by string alone, so the text is rendered white and
+// tinted to color.
+func (ui *ui) drawText(s string, color sdl.Color, size FontSize, x, y int32) int32 {
+	tex := ui.stringToTexture(s, sdl.Color{R: 255, G: 255, B: 255, A: 0}, size)
+	tex.SetColorMod(color.R, color.G, color.B)
+	_, _, w, h, err := tex.Query()
+	if err != nil {
+		panic(err)
+	}
+	ui.renderer.Copy(tex, nil, &sdl.Rect{X: x, Y: y, W: w, H: h})
+	return h
+}
@@ -0,0 +1,116 @@
+package ui2d
+
+import (
+	"strconv"
+
+	"github.com/LucasK1/gameswithgo/rpg/game"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// shopScreen is which offer the player has picked in a shop. Left and Right
+// switch between buying and selling, Up and Down pick an offer, Return takes
+// it and Escape leaves.
+type shopScreen struct {
+	open     bool
+	selling  bool
+	selected int
+}
+
+// shopInput handles one frame of keys in a shop, returning the input to send,
+// if any, and whether the shop needs redrawing.
+func (ui *ui) shopInput(shop *game.ShopView) (*game.Input, bool) {
+	s := &ui.shop
+	if !s.open {
+		*s = shopScreen{open: true}
+	}
+	offers := shop.Buy
+	if s.selling {
+		offers = shop.Sell
+	}
+	// Selling the last of something takes it off the list.
+	s.selected = clamp(s.selected, 0, len(offers)-1)
+	if s.selected < 0 {
+		s.selected = 0
+	}
+
+	key, pressed := ui.pressedKey()
+	if !pressed {
+		return nil, false
+	}
+	switch key {
+	case sdl.SCANCODE_LEFT, sdl.SCANCODE_RIGHT:
+		s.selling = !s.selling
+		s.selected = 0
+	case sdl.SCANCODE_UP:
+		if s.selected > 0 {
+			s.selected--
+		}
+	case sdl.SCANCODE_DOWN:
+		if s.selected < len(offers)-1 {
+			s.selected++
+		}
+	case sdl.SCANCODE_RETURN:
+		if s.selected >= len(offers) {
+			return nil, false
+		}
+		input := &game.Input{Type: game.Buy, Choice: s.selected}
+		if s.selling {
+			input.Type = game.Sell
+		}
+		return input, false
+	case sdl.SCANCODE_ESCAPE:
+		s.open = false
+		return &game.Input{Type: game.Choose, Choice: -1}, false
+	default:
+		return nil, false
+	}
+	return nil, true
+}
+
+// drawShop draws what the shop sells and what it would pay for the player's
+// things side by side in the middle of the window.
+func (ui *ui) drawShop(shop *game.ShopView, gold int) {
+	white := sdl.Color{R: 255, G: 255, B: 255, A: 0}
+	yellow := sdl.Color{R: 255, G: 255, B: 0, A: 0}
+	grey := sdl.Color{R: 160, G: 160, B: 160, A: 0}
+
+	margin := int32(ui.winWidth / 8)
+	width := int32(ui.winWidth) - 2*margin
+	height := int32(ui.winHeight) - 2*margin
+	ui.renderer.Copy(ui.eventBackground, nil, &sdl.Rect{X: margin, Y: margin, W: width, H: height})
+
+	y := margin + 10
+	y += ui.drawText(shop.Title, yellow, FontMedium, margin+10, y)
+	y += ui.drawText("Gold: "+strconv.Itoa(gold), white, FontSmall, margin+10, y)
+	y += 10
+
+	columns := [][]game.Offer{shop.Buy, shop.Sell}
+	headings := []string{"Buy", "Sell"}
+	for c, offers := range columns {
+		x := margin + 10 + int32(c)*width/2
+		selling := c == 1
+		color := grey
+		if selling == ui.shop.selling {
+			color = yellow
+		}
+		rowY := y + ui.drawText(headings[c], color, FontSmall, x, y)
+		if len(offers) == 0 {
+			ui.drawText("  nothing", grey, FontSmall, x, rowY)
+		}
+		for i, offer := range offers {
+			color := white
+			prefix := "  "
+			if selling == ui.shop.selling && i == ui.shop.selected {
+				color = yellow
+				prefix = "> "
+			}
+			if offer.Count == 0 {
+				color = grey
+			}
+			text := prefix + offer.Item + " x" + strconv.Itoa(offer.Count) + "  " + strconv.Itoa(offer.Price) + " gold"
+			rowY += ui.drawText(text, color, FontSmall, x, rowY)
+		}
+	}
+
+	ui.drawText("Left/Right: buy or sell   Return: trade   Esc: leave", grey, FontSmall, margin+10, margin+height-40)
+}
@@ -0,0 +1,180 @@
+package ui2d
+
+import (
+	"os"
+
+	"github.com/LucasK1/gameswithgo/rpg/atlas"
+	"github.com/LucasK1/gameswithgo/rpg/game"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const (
+	atlasFile    = assetsDir + "atlas.json"
+	oldAtlasFile = assetsDir + "atlas-index.txt"
+)
+
+// sprite is an atlas sprite ready to draw.
+type sprite struct {
+	tex         *sdl.Texture
+	variants    [][]sdl.Rect
+	weights     []int
+	totalWeight int
+	frameTime   uint32
+}
+
+func (s *sprite) animated() bool {
+	for _, frames := range s.variants {
+		if len(frames) > 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// variation picks a variant by weight, using n as the random number.
+func (s *sprite) variation(n int) int {
+	n %= s.totalWeight
+	for i, weight := range s.weights {
+		if n < weight {
+			return i
+		}
+		n -= weight
+	}
+	return len(s.weights) - 1
+}
+
+// frame is the part of the atlas to draw for variation at time now.
+func (s *sprite) frame(variation int, now uint32) sdl.Rect {
+	frames := s.variants[variation]
+	return frames[int(now/s.frameTime)%len(frames)]
+}
+
+// loadAtlas loads atlas.json and its images, converting atlas-index.txt
+// instead if there is no atlas.json yet.
+func (ui *ui) loadAtlas() {
+	a, err := atlas.Read(atlasFile)
+	if os.IsNotExist(err) {
+		infile, err := os.Open(oldAtlasFile)
+		if err != nil {
+			panic(err)
+		}
+		defer infile.Close()
+		a, err = atlas.ReadIndex(infile, "tiles.png")
+		if err != nil {
+			panic(err)
+		}
+		a.FillDefaults()
+	} else if err != nil {
+		panic(err)
+	}
+
+	ui.atlasTextures = make(map[string]*sdl.Texture)
+	for name, file := range a.Images {
+		ui.atlasTextures[name] = ui.imgFileToTexture(assetsDir + file)
+	}
+
+	ui.sprites = make(map[rune]*sprite)
+	ui.frameTime = 0
+	for _, info := range a.Sprites {
+		r, hasRune := info.RuneValue()
+		if !hasRune {
+			continue
+		}
+		s := &sprite{tex: ui.atlasTextures[info.Image], frameTime: uint32(info.FrameTime)}
+		for _, v := range info.Variants {
+			var frames []sdl.Rect
+			for _, f := range v.Frames {
+				frames = append(frames, sdl.Rect{X: int32(f.X), Y: int32(f.Y), W: int32(f.W), H: int32(f.H)})
+			}
+			s.variants = append(s.variants, frames)
+			s.weights = append(s.weights, v.Weight)
+			s.totalWeight += v.Weight
+		}
+		ui.sprites[r] = s
+		if s.animated() && (ui.frameTime == 0 || s.frameTime < ui.frameTime) {
+			ui.frameTime = s.frameTime
+		}
+	}
+}
+
+func (ui *ui) isAnimated(r rune) bool {
+	s, exists := ui.sprites[r]
+	return exists && s.animated()
+}
+
+// drawSprite draws variation of the sprite for r at dst. Runes the atlas has
+// no sprite for aren't drawn.
+func (ui *ui) drawSprite(r rune, variation int, dst *sdl.Rect) {
+	s, exists := ui.sprites[r]
+	if !exists {
+		return
+	}
+	src := s.frame(variation, ui.anim.now)
+	ui.renderer.Copy(s.tex, &src, dst)
+}
+
+// drawNPC draws an NPC's sprite, or a green player if the atlas has none
+// for its rune.
+func (ui *ui) drawNPC(r rune, dst *sdl.Rect, light game.Light) {
+	_, exists := ui.sprites[r]
+	if exists {
+		ui.tintLight(light)
+		ui.drawSprite(r, 0, dst)
+		ui.tint(255, 255, 255)
+		return
+	}
+	red, green, blue := litColor(light)
+	ui.tint(red/2, green, blue/2)
+	ui.drawSprite('@', 0, dst)
+	ui.tint(255, 255, 255)
+}
+
+// tint sets the colour sprites are multiplied by until it is set again.
+func (ui *ui) tint(r, g, b uint8) {
+	for _, tex := range ui.atlasTextures {
+		tex.SetColorMod(r, g, b)
+	}
+}
+
+// Runes an atlas can give sprites to for what killed monsters leave behind.
+const (
+	corpseRune rune = '%'
+	lootRune   rune = '$'
+)
+
+// drawCorpse draws the atlas's corpse sprite, or the monster's own sprite
+// upside down and drained of colour if the atlas has none.
+func (ui *ui) drawCorpse(corpse *game.Corpse, dst *sdl.Rect, light game.Light) {
+	red, green, blue := litColor(light)
+	_, exists := ui.sprites[corpseRune]
+	if exists {
+		ui.tint(red, green, blue)
+		ui.drawSprite(corpseRune, 0, dst)
+		ui.tint(255, 255, 255)
+		return
+	}
+	s, exists := ui.sprites[corpse.Rune]
+	if !exists {
+		return
+	}
+	src := s.frame(0, ui.anim.now)
+	ui.tint(red/2, green/3, blue/3)
+	ui.renderer.CopyEx(s.tex, &src, dst, 0, nil, sdl.FLIP_VERTICAL)
+	ui.tint(255, 255, 255)
+}
+
+// drawLoot draws the atlas's loot sprite, or a little heap of gold if the
+// atlas has none.
+func (ui *ui) drawLoot(dst *sdl.Rect, light game.Light) {
+	red, green, blue := litColor(light)
+	_, exists := ui.sprites[lootRune]
+	if exists {
+		ui.tint(red, green, blue)
+		ui.drawSprite(lootRune, 0, dst)
+		ui.tint(255, 255, 255)
+		return
+	}
+	ui.renderer.SetDrawColor(red, uint8(int(green)*200/255), uint8(int(blue)*40/255), 255)
+	ui.renderer.FillRect(&sdl.Rect{X: dst.X + dst.W*3/8, Y: dst.Y + dst.H*5/8, W: dst.W / 4, H: dst.H / 4})
+	ui.renderer.SetDrawColor(0, 0, 0, 255)
+}
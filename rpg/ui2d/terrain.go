@@ -0,0 +1,275 @@
+package ui2d
+
+import (
+	"hash/fnv"
+	"math/rand"
+
+	"github.com/LucasK1/gameswithgo/rpg/game"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// tileLook is everything that decides how a map tile is drawn.
+type tileLook struct {
+	tile    game.Tile
+	debug   bool
+	shown   bool
+	editing bool
+}
+
+// terrain keeps the map tiles of the current level drawn, at the current tile
+// size, in a texture. Each frame only the tiles that look different since the
+// last one are drawn again, which are the ones a door or the field of view
+// changed, and only the part of the texture in the window is copied to it.
+type terrain struct {
+	tex      *sdl.Texture
+	level    string
+	width    int
+	height   int
+	tileSize int32
+	drawn    [][]tileLook
+	valid    bool
+	// variations holds a random number for every tile of every level seen so
+	// far, which picks the tile's variation from the atlas.
+	variations map[string][][]int
+}
+
+// invalidate makes the next update draw every tile again.
+func (t *terrain) invalidate() {
+	t.valid = false
+}
+
+func (ui *ui) tileVariations(level *game.Snapshot) [][]int {
+	if ui.terrain.variations == nil {
+		ui.terrain.variations = make(map[string][][]int)
+	}
+	variations, exists := ui.terrain.variations[level.Name]
+	if exists && len(variations) == len(level.Map) && (len(variations) == 0 || len(variations[0]) == len(level.Map[0])) {
+		return variations
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(level.Name))
+	r := rand.New(rand.NewSource(int64(h.Sum64())))
+	variations = make([][]int, len(level.Map))
+	for y, row := range level.Map {
+		variations[y] = make([]int, len(row))
+		for x := range row {
+			variations[y][x] = r.Int()
+		}
+	}
+	ui.terrain.variations[level.Name] = variations
+	return variations
+}
+
+// updateTerrain brings the terrain texture up to date with level.
+func (ui *ui) updateTerrain(level *game.Snapshot) {
+	t := &ui.terrain
+	ts := ui.camera.tileSize()
+	height := len(level.Map)
+	width := 0
+	if height > 0 {
+		width = len(level.Map[0])
+	}
+
+	if t.tex == nil || t.level != level.Name || t.width != width || t.height != height || t.tileSize != ts {
+		if t.tex != nil {
+			t.tex.Destroy()
+		}
+		tex, err := ui.renderer.CreateTexture(sdl.PIXELFORMAT_RGBA8888, sdl.TEXTUREACCESS_TARGET, int32(width)*ts, int32(height)*ts)
+		if err != nil {
+			panic(err)
+		}
+		t.tex = tex
+		t.level = level.Name
+		t.width = width
+		t.height = height
+		t.tileSize = ts
+		t.drawn = make([][]tileLook, height)
+		for y := range t.drawn {
+			t.drawn[y] = make([]tileLook, width)
+		}
+		t.valid = false
+	}
+
+	variations := ui.tileVariations(level)
+
+	targeting := false
+	for y, row := range level.Map {
+		for x, tile := range row {
+			pos := game.Pos{X: x, Y: y}
+			if !tile.Visible {
+				// Only the light on visible tiles is drawn, so changes
+				// elsewhere needn't redraw anything.
+				tile.Light = game.Light{}
+			}
+			look := tileLook{tile: tile, debug: level.Debug[pos], shown: tile.Visible || tile.Seen || ui.editor.active, editing: ui.editor.active}
+			if t.valid && t.drawn[y][x] == look {
+				continue
+			}
+			if !targeting {
+				err := ui.renderer.SetRenderTarget(t.tex)
+				if err != nil {
+					panic(err)
+				}
+				targeting = true
+			}
+			t.drawn[y][x] = look
+			ui.drawTerrainTile(x, y, look, variations[y][x])
+		}
+	}
+	t.valid = true
+	if !targeting {
+		return
+	}
+
+	ui.tint(255, 255, 255)
+	ui.renderer.SetDrawColor(0, 0, 0, 255)
+	err := ui.renderer.SetRenderTarget(nil)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// drawTerrainTile draws one tile into the terrain texture. Animated tiles,
+// and whatever overlays an animated tile, are left out here and drawn over
+// the texture each frame instead.
+func (ui *ui) drawTerrainTile(x, y int, look tileLook, n int) {
+	ts := ui.terrain.tileSize
+	dstRect := sdl.Rect{X: int32(x) * ts, Y: int32(y) * ts, W: ts, H: ts}
+	ui.renderer.SetDrawColor(0, 0, 0, 255)
+	ui.renderer.FillRect(&dstRect)
+
+	tile := look.tile
+	if tile.Rune == game.Blank || !look.shown {
+		return
+	}
+
+	if ui.isAnimated(tile.Rune) {
+		return
+	}
+	ui.tintTile(look)
+	ui.drawSprite(tile.Rune, ui.variation(tile.Rune, n), &dstRect)
+	if tile.OverlayRune != game.Blank && !ui.isAnimated(tile.OverlayRune) {
+		ui.drawSprite(tile.OverlayRune, 0, &dstRect)
+	}
+}
+
+// variation picks the variation of r to draw where the tile's random number
+// is n.
+func (ui *ui) variation(r rune, n int) int {
+	s, exists := ui.sprites[r]
+	if !exists {
+		return 0
+	}
+	return s.variation(n)
+}
+
+func (ui *ui) tintTile(look tileLook) {
+	if look.debug {
+		ui.tint(128, 0, 0)
+	} else if look.editing {
+		ui.tint(255, 255, 255)
+	} else if look.tile.Visible {
+		ui.tintLight(look.tile.Light)
+	} else if look.tile.Seen {
+		ui.tint(128, 128, 128)
+	} else {
+		ui.tint(255, 255, 255)
+	}
+}
+
+// minLight is the least each colour is tinted by on a visible tile, so that
+// what the player can see in the dark isn't drawn black.
+const minLight = 64
+
+// litColor is the tint for something standing in light.
+func litColor(light game.Light) (r, g, b uint8) {
+	channel := func(c uint8) uint8 {
+		if c < minLight {
+			return minLight
+		}
+		return c
+	}
+	return channel(light.R), channel(light.G), channel(light.B)
+}
+
+func (ui *ui) tintLight(light game.Light) {
+	ui.tint(litColor(light))
+}
+
+// lightAt is the light on the tile at pos, or full light in the editor.
+func (ui *ui) lightAt(level *game.Snapshot, pos game.Pos) game.Light {
+	if ui.editor.active || pos.Y < 0 || pos.Y >= len(level.Map) || pos.X < 0 || pos.X >= len(level.Map[pos.Y]) {
+		return game.Daylight
+	}
+	return level.Map[pos.Y][pos.X].Light
+}
+
+// tintLit tints what is drawn next by the light at pos.
+func (ui *ui) tintLit(level *game.Snapshot, pos game.Pos) {
+	ui.tintLight(ui.lightAt(level, pos))
+}
+
+// viewRange is the range of tiles, end exclusive, that fall inside the window.
+func (ui *ui) viewRange(offsetX, offsetY int32) (x0, y0, x1, y1 int) {
+	t := &ui.terrain
+	ts := t.tileSize
+	x0 = clamp(int(-offsetX/ts), 0, t.width)
+	y0 = clamp(int(-offsetY/ts), 0, t.height)
+	x1 = clamp(int((int32(ui.winWidth)-offsetX)/ts)+1, 0, t.width)
+	y1 = clamp(int((int32(ui.winHeight)-offsetY)/ts)+1, 0, t.height)
+	return x0, y0, x1, y1
+}
+
+func clamp(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+// drawTerrain copies the part of the terrain texture inside the window to
+// it, then draws any animated tiles on top.
+func (ui *ui) drawTerrain(level *game.Snapshot, offsetX, offsetY int32) {
+	t := &ui.terrain
+	ts := t.tileSize
+	x0, y0, x1, y1 := ui.viewRange(offsetX, offsetY)
+	if x1 <= x0 || y1 <= y0 {
+		return
+	}
+
+	srcRect := sdl.Rect{X: int32(x0) * ts, Y: int32(y0) * ts, W: int32(x1-x0) * ts, H: int32(y1-y0) * ts}
+	dstRect := sdl.Rect{X: int32(x0)*ts + offsetX, Y: int32(y0)*ts + offsetY, W: srcRect.W, H: srcRect.H}
+	ui.renderer.Copy(t.tex, &srcRect, &dstRect)
+
+	if ui.frameTime == 0 {
+		return
+	}
+	variations := ui.tileVariations(level)
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1; x++ {
+			look := t.drawn[y][x]
+			tile := look.tile
+			if tile.Rune == game.Blank || !look.shown {
+				continue
+			}
+			baseAnimated := ui.isAnimated(tile.Rune)
+			overlayAnimated := ui.isAnimated(tile.OverlayRune)
+			if !baseAnimated && !overlayAnimated {
+				continue
+			}
+			dstRect := sdl.Rect{X: int32(x)*ts + offsetX, Y: int32(y)*ts + offsetY, W: ts, H: ts}
+			ui.tintTile(look)
+			if baseAnimated {
+				ui.drawSprite(tile.Rune, ui.variation(tile.Rune, variations[y][x]), &dstRect)
+			}
+			if tile.OverlayRune != game.Blank {
+				ui.drawSprite(tile.OverlayRune, 0, &dstRect)
+			}
+		}
+	}
+	ui.tint(255, 255, 255)
+}
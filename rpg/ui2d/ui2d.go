@@ -1,12 +1,10 @@
 package ui2d
 
 import (
-	"bufio"
+	"fmt"
 	"image/png"
-	"math/rand"
 	"os"
 	"strconv"
-	"strings"
 
 	"github.com/LucasK1/gameswithgo/rpg/game"
 	"github.com/veandco/go-sdl2/mix"
@@ -14,30 +12,21 @@ import (
 	"github.com/veandco/go-sdl2/ttf"
 )
 
-type sounds struct {
-	doorOpens []*mix.Chunk
-	footsteps []*mix.Chunk
-}
-
-func playRandomSound(chunks []*mix.Chunk, volume int) {
-	chunkIndex := rand.Intn(len(chunks))
-	chunks[chunkIndex].Volume(volume)
-	chunks[chunkIndex].Play(-1, 0)
-}
+const assetsDir = "/home/lucask/go-dev/src/github.com/LucasK1/gameswithgo/rpg/ui2d/assets/"
 
 type ui struct {
 	winWidth          int
 	winHeight         int
 	renderer          *sdl.Renderer
 	window            *sdl.Window
-	textureAtlas      *sdl.Texture
-	textureIndex      map[rune][]sdl.Rect
+	atlasTextures     map[string]*sdl.Texture
+	sprites           map[rune]*sprite
+	frameTime         uint32
+	anim              animator
 	keyboardState     []uint8
 	prevKeyboardState []uint8
-	centerX           int
-	centerY           int
-	r                 *rand.Rand
-	levelChan         chan *game.Level
+	camera            camera
+	levelChan         chan *game.Snapshot
 	inputChan         chan *game.Input
 	fontSmall         *ttf.Font
 	fontMedium        *ttf.Font
@@ -46,53 +35,83 @@ type ui struct {
 	strToTexMd        map[string]*sdl.Texture
 	strToTexLg        map[string]*sdl.Texture
 	eventBackground   *sdl.Texture
-	sounds            sounds
+	audio             *audio
+	level             *game.Snapshot
+	editor            editor
+	bindings          bindings
+	held              map[game.InputType]*heldAction
+	controllers       []*sdl.GameController
+	prevButtons       [sdl.CONTROLLER_BUTTON_MAX]bool
+	rebind            rebindScreen
+	menu              menu
+	shop              shopScreen
+	mouse             mouse
+	minimap           minimap
+	terrain           terrain
 }
 
-func NewUI(inputChan chan *game.Input, levelChan chan *game.Level) *ui {
+func NewUI(inputChan chan *game.Input, levelChan chan *game.Snapshot) *ui {
+	winWidth, winHeight := 1280, 720
 
-	ui := &ui{}
-	ui.strToTexSm = make(map[string]*sdl.Texture)
-	ui.strToTexMd = make(map[string]*sdl.Texture)
-	ui.strToTexLg = make(map[string]*sdl.Texture)
-	ui.inputChan = inputChan
-	ui.levelChan = levelChan
-	ui.r = rand.New(rand.NewSource(1))
-	ui.winHeight = 720
-	ui.winWidth = 1280
-
-	window, err := sdl.CreateWindow("RPG", 200, 200, int32(ui.winWidth), int32(ui.winHeight), sdl.WINDOW_SHOWN)
+	window, err := sdl.CreateWindow("RPG", 200, 200, int32(winWidth), int32(winHeight), sdl.WINDOW_SHOWN|sdl.WINDOW_RESIZABLE)
 	if err != nil {
 		panic(err)
 	}
-	ui.window = window
 
-	ui.renderer, err = sdl.CreateRenderer(ui.window, -1, sdl.RENDERER_ACCELERATED)
+	renderer, err := sdl.CreateRenderer(window, -1, sdl.RENDERER_ACCELERATED|sdl.RENDERER_TARGETTEXTURE)
 	if err != nil {
 		panic(err)
 	}
 
-	sdl.SetHint(sdl.HINT_RENDER_SCALE_QUALITY, "1")
-
-	ui.textureAtlas = ui.imgFileToTexture("/home/lucask/go-dev/src/github.com/LucasK1/gameswithgo/rpg/ui2d/assets/tiles.png")
-	ui.loadTextureIndex()
+	ui := newUI(renderer, winWidth, winHeight)
+	ui.window = window
+	ui.inputChan = inputChan
+	ui.levelChan = levelChan
 
 	ui.keyboardState = sdl.GetKeyboardState()
 	ui.prevKeyboardState = make([]uint8, len(ui.keyboardState))
 	copy(ui.prevKeyboardState, ui.keyboardState)
 
-	ui.centerX = -1
-	ui.centerY = -1
+	ui.bindings = loadBindings()
+	ui.held = make(map[game.InputType]*heldAction)
+	ui.openControllers()
 
-	ui.fontSmall, err = ttf.OpenFont("/home/lucask/go-dev/src/github.com/LucasK1/gameswithgo/rpg/ui2d/assets/font.ttf", int(float64(ui.winHeight)*0.025))
+	backend, err := newSDLAudio()
 	if err != nil {
-		panic(err)
+		fmt.Fprintln(os.Stderr, "Playing without sound:", err)
+		ui.audio = newAudio(&nullAudio{})
+	} else {
+		ui.audio = newAudio(backend)
 	}
-	ui.fontMedium, err = ttf.OpenFont("/home/lucask/go-dev/src/github.com/LucasK1/gameswithgo/rpg/ui2d/assets/font.ttf", 32)
+	ui.audio.playMusic("ambient.ogg")
+
+	return ui
+}
+
+// newUI sets up everything needed to draw to renderer, which is all a
+// benchmark needs.
+func newUI(renderer *sdl.Renderer, winWidth, winHeight int) *ui {
+	ui := &ui{}
+	ui.strToTexSm = make(map[string]*sdl.Texture)
+	ui.strToTexMd = make(map[string]*sdl.Texture)
+	ui.strToTexLg = make(map[string]*sdl.Texture)
+	ui.winHeight = winHeight
+	ui.winWidth = winWidth
+	ui.renderer = renderer
+
+	sdl.SetHint(sdl.HINT_RENDER_SCALE_QUALITY, "1")
+
+	ui.loadAtlas()
+
+	ui.camera = newCamera()
+
+	ui.openSmallFont()
+	var err error
+	ui.fontMedium, err = ttf.OpenFont(assetsDir+"font.ttf", 32)
 	if err != nil {
 		panic(err)
 	}
-	ui.fontLarge, err = ttf.OpenFont("/home/lucask/go-dev/src/github.com/LucasK1/gameswithgo/rpg/ui2d/assets/font.ttf", 64)
+	ui.fontLarge, err = ttf.OpenFont(assetsDir+"font.ttf", 64)
 	if err != nil {
 		panic(err)
 	}
@@ -100,42 +119,48 @@ func NewUI(inputChan chan *game.Input, levelChan chan *game.Level) *ui {
 	ui.eventBackground = ui.GetSinglePixelTex(sdl.Color{R: 0, G: 0, B: 0, A: 156})
 	ui.eventBackground.SetBlendMode(sdl.BLENDMODE_BLEND)
 
-	err = mix.OpenAudio(22050, mix.DEFAULT_FORMAT, 2, 4096)
-	if err != nil {
-		panic(err)
-	}
+	ui.audio = newAudio(&nullAudio{})
 
-	music, err := mix.LoadMUS("/home/lucask/go-dev/src/github.com/LucasK1/gameswithgo/rpg/ui2d/assets/ambient.ogg")
-	if err != nil {
-		panic(err)
+	return ui
+}
+
+// openSmallFont (re)opens the font used for the event log, which scales with
+// the window height.
+func (ui *ui) openSmallFont() {
+	if ui.fontSmall != nil {
+		ui.fontSmall.Close()
+		for s, tex := range ui.strToTexSm {
+			tex.Destroy()
+			delete(ui.strToTexSm, s)
+		}
 	}
 
-	err = music.Play(-1)
+	var err error
+	ui.fontSmall, err = ttf.OpenFont(assetsDir+"font.ttf", int(float64(ui.winHeight)*0.025))
 	if err != nil {
 		panic(err)
 	}
+}
 
-	footstepBase := "/home/lucask/go-dev/src/github.com/LucasK1/gameswithgo/rpg/ui2d/assets/footstep0"
-	for i := 0; i < 10; i++ {
-		footstepFile := footstepBase + strconv.Itoa(i) + ".ogg"
-		footstep, err := mix.LoadWAV(footstepFile)
-		if err != nil {
-			panic(err)
-		}
-		ui.sounds.footsteps = append(ui.sounds.footsteps, footstep)
+// resize lays the UI out again for a new window size.
+func (ui *ui) resize(width, height int) {
+	if width == ui.winWidth && height == ui.winHeight {
+		return
 	}
+	ui.winWidth = width
+	ui.winHeight = height
+	ui.openSmallFont()
+}
 
-	doorOpenBase := "/home/lucask/go-dev/src/github.com/LucasK1/gameswithgo/rpg/ui2d/assets/doorOpen_"
-	for i := 1; i < 3; i++ {
-		doorOpenFile := doorOpenBase + strconv.Itoa(i) + ".ogg"
-		doorOpen, err := mix.LoadWAV(doorOpenFile)
-		if err != nil {
-			panic(err)
-		}
-		ui.sounds.doorOpens = append(ui.sounds.doorOpens, doorOpen)
+func (ui *ui) toggleFullscreen() {
+	var flags uint32
+	if ui.window.GetFlags()&sdl.WINDOW_FULLSCREEN_DESKTOP == 0 {
+		flags = sdl.WINDOW_FULLSCREEN_DESKTOP
+	}
+	err := ui.window.SetFullscreen(flags)
+	if err != nil {
+		panic(err)
 	}
-
-	return ui
 }
 
 type FontSize int
@@ -194,49 +219,6 @@ func (ui *ui) stringToTexture(s string, color sdl.Color, size FontSize) *sdl.Tex
 	return tex
 }
 
-func (ui *ui) loadTextureIndex() {
-	ui.textureIndex = make(map[rune][]sdl.Rect)
-
-	infile, err := os.Open("/home/lucask/go-dev/src/github.com/LucasK1/gameswithgo/rpg/ui2d/assets/atlas-index.txt")
-	if err != nil {
-		panic(err)
-	}
-
-	scanner := bufio.NewScanner(infile)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-
-		tileRune := rune(line[0])
-		xy := line[1:]
-
-		splitXYC := strings.Split(xy, ",")
-
-		x, err := strconv.ParseInt(strings.TrimSpace(splitXYC[0]), 10, 64)
-		if err != nil {
-			panic(err)
-		}
-		y, err := strconv.ParseInt(strings.TrimSpace(splitXYC[1]), 10, 64)
-		if err != nil {
-			panic(err)
-		}
-		variationCount, err := strconv.ParseInt(strings.TrimSpace(splitXYC[2]), 10, 64)
-		if err != nil {
-			panic(err)
-		}
-
-		var rects []sdl.Rect
-		for i := 0; i < int(variationCount); i++ {
-			rects = append(rects, sdl.Rect{X: int32(x * 32), Y: int32(y * 32), W: 32, H: 32})
-			x++
-			if x > 62 {
-				x = 0
-				y++
-			}
-		}
-		ui.textureIndex[tileRune] = rects
-	}
-}
-
 func (ui *ui) imgFileToTexture(filename string) *sdl.Texture {
 	infile, err := os.Open(filename)
 	if err != nil {
@@ -301,78 +283,77 @@ func init() {
 
 }
 
-func (ui *ui) Draw(level *game.Level) {
+func (ui *ui) Draw(level *game.Snapshot) {
 	p := level.Player
 
-	if ui.centerX == -1 && ui.centerY == -1 {
-		ui.centerX = p.X
-		ui.centerY = p.Y
-	}
-
-	limit := 5
-
-	if p.X > ui.centerX+limit {
-		diff := level.Player.X - (ui.centerX + limit)
-		ui.centerX += diff
-	} else if p.X < ui.centerX-limit {
-		diff := (ui.centerX - limit) - level.Player.X
-		ui.centerX -= diff
-	} else if p.Y > ui.centerY+limit {
-		diff := level.Player.Y - (ui.centerY + limit)
-		ui.centerY += diff
-	} else if p.Y < ui.centerY-limit {
-		diff := (ui.centerY - limit) - level.Player.Y
-		ui.centerY -= diff
+	if ui.editor.active {
+		p.Pos = ui.editor.cursor
 	}
 
-	offsetX := int32((ui.winWidth / 2) - ui.centerX*32)
-	offsetY := int32((ui.winHeight / 2) - ui.centerY*32)
+	ui.camera.follow(p.Pos)
+	ts := ui.camera.tileSize()
+	offsetX, offsetY := ui.camera.offset(ui.winWidth, ui.winHeight)
 
 	ui.renderer.Clear()
-	ui.r.Seed(1)
-
-	for y, row := range level.Map {
-		for x, tile := range row {
-			if tile.Rune != game.Blank {
-				srcRects := ui.textureIndex[tile.Rune]
-				srcRect := srcRects[ui.r.Intn(len(srcRects))]
-				if tile.Visible || tile.Seen {
-					dstRect := sdl.Rect{X: int32(x)*32 + offsetX, Y: int32(y)*32 + offsetY, W: 32, H: 32}
-
-					pos := game.Pos{X: x, Y: y}
-					if level.Debug[pos] {
-						ui.textureAtlas.SetColorMod(128, 0, 0)
-					} else if tile.Seen && !tile.Visible {
-						ui.textureAtlas.SetColorMod(128, 128, 128)
-					} else {
-						ui.textureAtlas.SetColorMod(255, 255, 255)
-					}
-
-					ui.renderer.Copy(ui.textureAtlas, &srcRect, &dstRect)
-
-					if tile.OverlayRune != game.Blank {
-						srcRect := ui.textureIndex[tile.OverlayRune][0]
-						ui.renderer.Copy(ui.textureAtlas, &srcRect, &dstRect)
+	ui.updateMinimap(level)
+	if ui.minimap.fullscreen && !ui.editor.active {
+		ui.drawMinimap(level)
+		ui.renderer.Present()
+		return
+	}
+	ui.updateTerrain(level)
+	ui.drawTerrain(level, offsetX, offsetY)
+	x0, y0, x1, y1 := ui.viewRange(offsetX, offsetY)
+	inView := func(pos game.Pos) bool {
+		return pos.X >= x0 && pos.X < x1 && pos.Y >= y0 && pos.Y < y1
+	}
 
-					}
-				}
-			}
+	// Remains go beneath anyone standing on them.
+	for pos, corpse := range level.Corpses {
+		if (level.Map[pos.Y][pos.X].Visible || ui.editor.active) && inView(pos) {
+			ui.drawCorpse(corpse, &sdl.Rect{X: int32(pos.X)*ts + offsetX, Y: int32(pos.Y)*ts + offsetY, W: ts, H: ts}, ui.lightAt(level, pos))
+		}
+	}
+	for pos := range level.Loot {
+		if (level.Map[pos.Y][pos.X].Visible || ui.editor.active) && inView(pos) {
+			ui.drawLoot(&sdl.Rect{X: int32(pos.X)*ts + offsetX, Y: int32(pos.Y)*ts + offsetY, W: ts, H: ts}, ui.lightAt(level, pos))
 		}
 	}
-	ui.textureAtlas.SetColorMod(255, 255, 255)
 
 	for pos, monster := range level.Monsters {
 
-		if level.Map[pos.Y][pos.X].Visible {
-			monsterSrcRect := ui.textureIndex[monster.Rune][0]
+		if (level.Map[pos.Y][pos.X].Visible || ui.editor.active) && inView(pos) {
+			x, y := ui.anim.monsterAt(monster)
+			ui.tintLit(level, pos)
+			if ui.anim.monsterFlashing(monster) {
+				ui.tint(255, 64, 64)
+			}
+			ui.drawSprite(monster.Rune, 0, &sdl.Rect{X: int32(x*float64(ts)) + offsetX, Y: int32(y*float64(ts)) + offsetY, W: ts, H: ts})
+			ui.tint(255, 255, 255)
+		}
+	}
 
-			ui.renderer.Copy(ui.textureAtlas, &monsterSrcRect, &sdl.Rect{X: int32(pos.X)*32 + offsetX, Y: int32(pos.Y)*32 + offsetY, W: 32, H: 32})
+	for pos, npc := range level.NPCs {
+		if (level.Map[pos.Y][pos.X].Visible || ui.editor.active) && inView(pos) {
+			ui.drawNPC(npc.Rune, &sdl.Rect{X: int32(pos.X)*ts + offsetX, Y: int32(pos.Y)*ts + offsetY, W: ts, H: ts}, ui.lightAt(level, pos))
 		}
 	}
 
-	playerSrcRect := ui.textureIndex['@'][0]
+	for pos, other := range level.Others {
+		if level.Map[pos.Y][pos.X].Visible && inView(pos) {
+			ui.tintLit(level, pos)
+			ui.drawSprite(other.Rune, 0, &sdl.Rect{X: int32(pos.X)*ts + offsetX, Y: int32(pos.Y)*ts + offsetY, W: ts, H: ts})
+			ui.tint(255, 255, 255)
+		}
+	}
 
-	ui.renderer.Copy(ui.textureAtlas, &playerSrcRect, &sdl.Rect{X: int32(p.X)*32 + offsetX, Y: int32(p.Y)*32 + offsetY, W: 32, H: 32})
+	playerX, playerY := ui.anim.playerAt(level.Player.Pos)
+	ui.tintLit(level, level.Player.Pos)
+	if ui.anim.playerFlashing() {
+		ui.tint(255, 64, 64)
+	}
+	ui.drawSprite('@', 0, &sdl.Rect{X: int32(playerX*float64(ts)) + offsetX, Y: int32(playerY*float64(ts)) + offsetY, W: ts, H: ts})
+	ui.tint(255, 255, 255)
 
 	textStart := int32(float64(ui.winHeight) * 0.69)
 	textWidth := int32(float64(ui.winWidth) * 0.25)
@@ -401,9 +382,47 @@ func (ui *ui) Draw(level *game.Level) {
 		count++
 	}
 
+	if ui.editor.active {
+		ui.drawEditor(level, offsetX, offsetY)
+	} else {
+		ui.drawMinimap(level)
+		y := 10 + ui.drawText("Gold: "+strconv.Itoa(level.Player.Gold), sdl.Color{R: 255, G: 215, B: 0, A: 0}, FontSmall, 10, 10)
+		ui.drawQuests(level.Quests, y)
+		if level.Dialogue != nil {
+			ui.drawDialogue(level.Dialogue)
+		}
+		if level.Shop != nil {
+			ui.drawShop(level.Shop, level.Player.Gold)
+		}
+		if level.Travel != nil {
+			ui.renderer.SetDrawColor(255, 255, 0, 255)
+			ui.renderer.DrawRect(&sdl.Rect{X: int32(level.Travel.X)*ts + offsetX, Y: int32(level.Travel.Y)*ts + offsetY, W: ts, H: ts})
+			ui.renderer.SetDrawColor(0, 0, 0, 255)
+		}
+		if level.Dialogue == nil && level.Shop == nil && ui.menu.screen == noMenu {
+			ui.drawTooltip(level)
+		}
+	}
+	if ui.menu.screen != noMenu {
+		ui.drawMenu()
+	}
+
 	ui.renderer.Present()
 }
 
+// redraw draws the last snapshot again, for when something other than the
+// game changed what is on screen.
+func (ui *ui) redraw() {
+	if ui.rebind.active {
+		ui.drawRebind()
+	} else if ui.level != nil {
+		ui.Draw(ui.level)
+	} else if ui.menu.screen != noMenu {
+		ui.drawMenu()
+		ui.renderer.Present()
+	}
+}
+
 func (ui *ui) keyDownOnce(key uint8) bool {
 	return ui.keyboardState[key] == 1 && ui.prevKeyboardState[key] == 0
 }
@@ -435,42 +454,198 @@ func (ui *ui) Run() {
 			case *sdl.QuitEvent:
 				ui.inputChan <- &game.Input{Type: game.QuitGame}
 			case *sdl.WindowEvent:
-				if e.Event == sdl.WINDOWEVENT_CLOSE {
+				switch e.Event {
+				case sdl.WINDOWEVENT_CLOSE:
 					ui.inputChan <- &game.Input{Type: game.CloseWindow, LevelChannel: ui.levelChan}
+				case sdl.WINDOWEVENT_SIZE_CHANGED:
+					ui.resize(int(e.Data1), int(e.Data2))
+					ui.redraw()
+				}
+			case *sdl.RenderEvent:
+				if e.Type == sdl.RENDER_TARGETS_RESET {
+					ui.terrain.invalidate()
+					ui.redraw()
+				}
+			case *sdl.ControllerDeviceEvent:
+				if e.Type == sdl.CONTROLLERDEVICEADDED {
+					ui.openController(int(e.Which))
+				} else if e.Type == sdl.CONTROLLERDEVICEREMOVED {
+					ui.closeController(e.Which)
 				}
 			}
 		}
 
+		now := sdl.GetTicks()
+		ui.anim.tick(now)
+
 		select {
 		case newLevel, ok := <-ui.levelChan:
 			if ok {
-				switch newLevel.LastEvent {
-				case game.Move:
-					playRandomSound(ui.sounds.footsteps, 10)
-				case game.DoorOpen:
-					playRandomSound(ui.sounds.doorOpens, 32)
-				default:
-
+				if ui.level == nil || ui.level.Name != newLevel.Name {
+					ui.camera.jump(newLevel.Player.Pos)
+					ui.editor.cursor = newLevel.Player.Pos
+				}
+				if ui.editor.active {
+					ui.anim.reset()
+				} else {
+					ui.anim.observe(ui.level, newLevel)
+				}
+				ui.level = newLevel
+				ui.audio.playMusic(newLevel.Music)
+				if !ui.editor.active {
+					for _, event := range newLevel.TurnEvents {
+						ui.audio.playEvent(event.Type, event.Pos, newLevel.Player.Pos)
+					}
+				}
+				if ui.rebind.active {
+					ui.drawRebind()
+				} else {
+					ui.Draw(newLevel)
 				}
-				ui.Draw(newLevel)
 			}
 		default:
 		}
 
+		moved := ui.camera.update(now)
+		animating := ui.anim.needsRedraw(ui.frameTime)
+		if (moved || animating) && !ui.rebind.active {
+			ui.redraw()
+		}
+
+		button, buttonPressed := ui.pressedButton()
+
 		if sdl.GetKeyboardFocus() == ui.window || sdl.GetMouseFocus() == ui.window {
-			var input game.Input
-			if ui.keyDownOnce(sdl.SCANCODE_UP) {
-				input.Type = game.Up
+			if ui.rebind.active {
+				if ui.updateRebind(button, buttonPressed) {
+					if ui.rebind.active {
+						ui.drawRebind()
+					} else if ui.level != nil {
+						ui.Draw(ui.level)
+					}
+				}
+				copy(ui.prevKeyboardState, ui.keyboardState)
+				sdl.Delay(10)
+				continue
+			}
+			if ui.keyDownOnce(sdl.SCANCODE_F1) {
+				ui.toggleRebind()
+				ui.drawRebind()
+				copy(ui.prevKeyboardState, ui.keyboardState)
+				sdl.Delay(10)
+				continue
 			}
-			if ui.keyDownOnce(sdl.SCANCODE_DOWN) {
-				input.Type = game.Down
+			if ui.keyDownOnce(sdl.SCANCODE_F11) {
+				ui.toggleFullscreen()
 			}
-			if ui.keyDownOnce(sdl.SCANCODE_LEFT) {
-				input.Type = game.Left
+			audioChanged := true
+			switch {
+			case ui.keyDownOnce(sdl.SCANCODE_M):
+				ui.audio.toggleMute()
+			case ui.keyDownOnce(sdl.SCANCODE_F9):
+				ui.audio.changeMaster(-10)
+			case ui.keyDownOnce(sdl.SCANCODE_F10):
+				ui.audio.changeMaster(10)
+			default:
+				audioChanged = false
 			}
-			if ui.keyDownOnce(sdl.SCANCODE_RIGHT) {
-				input.Type = game.Right
+			if audioChanged {
+				err := ui.audio.save()
+				if err != nil {
+					panic(err)
+				}
 			}
+			if ui.menu.screen != noMenu {
+				input, redraw := ui.updateMenu()
+				copy(ui.prevKeyboardState, ui.keyboardState)
+				if input != nil {
+					ui.inputChan <- input
+				}
+				if redraw {
+					ui.redraw()
+				}
+				sdl.Delay(10)
+				continue
+			}
+			if ui.keyDownOnce(sdl.SCANCODE_EQUALS) || ui.keyDownOnce(sdl.SCANCODE_KP_PLUS) {
+				if ui.camera.zoomIn() {
+					ui.redraw()
+				}
+			}
+			if ui.keyDownOnce(sdl.SCANCODE_MINUS) || ui.keyDownOnce(sdl.SCANCODE_KP_MINUS) {
+				if ui.camera.zoomOut() {
+					ui.redraw()
+				}
+			}
+			if ui.keyDownOnce(sdl.SCANCODE_TAB) {
+				ui.minimap.fullscreen = !ui.minimap.fullscreen
+				ui.redraw()
+			}
+			if ui.keyDownOnce(sdl.SCANCODE_F2) {
+				input := ui.toggleEditor()
+				if input != nil {
+					ui.inputChan <- input
+				} else if ui.level != nil {
+					ui.Draw(ui.level)
+				}
+			}
+			if ui.editor.active {
+				input, redraw := ui.updateEditor()
+				copy(ui.prevKeyboardState, ui.keyboardState)
+				if input != nil {
+					ui.inputChan <- input
+				} else if redraw {
+					ui.Draw(ui.level)
+				}
+				sdl.Delay(10)
+				continue
+			}
+
+			if ui.level != nil && ui.level.Shop != nil {
+				input, redraw := ui.shopInput(ui.level.Shop)
+				copy(ui.prevKeyboardState, ui.keyboardState)
+				if input != nil {
+					ui.inputChan <- input
+				} else if redraw {
+					ui.Draw(ui.level)
+				}
+				sdl.Delay(10)
+				continue
+			}
+			ui.shop.open = false
+			if ui.level != nil && ui.level.Dialogue != nil {
+				input := ui.dialogueInput(ui.level.Dialogue)
+				copy(ui.prevKeyboardState, ui.keyboardState)
+				if input != nil {
+					ui.inputChan <- input
+				}
+				sdl.Delay(10)
+				continue
+			}
+
+			if ui.keyDownOnce(sdl.SCANCODE_ESCAPE) {
+				ui.openMenu(pauseMenu)
+				copy(ui.prevKeyboardState, ui.keyboardState)
+				ui.inputChan <- &game.Input{Type: game.Pause}
+				ui.redraw()
+				sdl.Delay(10)
+				continue
+			}
+
+			if ui.level != nil {
+				input, redraw := ui.updateMouse(now)
+				if redraw && input == nil {
+					ui.Draw(ui.level)
+				}
+				if input != nil {
+					copy(ui.prevKeyboardState, ui.keyboardState)
+					ui.inputChan <- input
+					sdl.Delay(10)
+					continue
+				}
+			}
+
+			var input game.Input
+			input.Type = ui.pollInput()
 
 			copy(ui.prevKeyboardState, ui.keyboardState)
 